@@ -0,0 +1,249 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"net"
+	"strings"
+	"time"
+)
+
+// Config is the server configuration. Every key is read from, in order of
+// precedence, the server flags, the TODO_* environment variables (like
+// TODO_LISTEN or TODO_POSTGRES_HOST) and the config file.
+type Config struct {
+	Listen       string         `mapstructure:"listen"`
+	Mode         string         `mapstructure:"mode"`
+	LogLevel     string         `mapstructure:"log_level"`
+	ReadTimeout  time.Duration  `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration  `mapstructure:"write_timeout"`
+	IdleTimeout  time.Duration  `mapstructure:"idle_timeout"`
+	Store        string         `mapstructure:"store"`
+	Postgres     PostgresConfig `mapstructure:"postgres"`
+	SQLite       SQLiteConfig   `mapstructure:"sqlite"`
+	Urgency      UrgencyWeights `mapstructure:"urgency"`
+	// TrashRetention is how long deleted todos are kept, 0 keeps them
+	// until the trash is emptied
+	TrashRetention time.Duration `mapstructure:"trash_retention"`
+	// UndoDepth is how many operations of a user can be undone
+	UndoDepth int `mapstructure:"undo_depth"`
+}
+
+// PostgresConfig holds the pieces of the Postgres DSN, an empty dbname
+// picks todo_cli or todo_cli_test depending on the mode
+type PostgresConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	DBName   string `mapstructure:"dbname"`
+	SSLMode  string `mapstructure:"sslmode"`
+}
+
+type SQLiteConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// configDefaults are the values used when a key is set nowhere else
+var configDefaults = map[string]interface{}{
+	"listen":            ":8080",
+	"mode":              "prod",
+	"log_level":         "error",
+	"read_timeout":      15 * time.Second,
+	"write_timeout":     15 * time.Second,
+	"idle_timeout":      60 * time.Second,
+	"trash_retention":   30 * 24 * time.Hour,
+	"undo_depth":        undoDepth,
+	"store":             "postgres",
+	"postgres.host":     "localhost",
+	"postgres.port":     5432,
+	"postgres.user":     "",
+	"postgres.password": "",
+	"postgres.dbname":   "",
+	"postgres.sslmode":  "prefer",
+	"sqlite.path":       "todo.db",
+	"urgency.priority":  urgencyWeights.Priority,
+	"urgency.due":       urgencyWeights.Due,
+	"urgency.age":       urgencyWeights.Age,
+	"urgency.tags":      urgencyWeights.Tags,
+}
+
+// configFlags maps the server flags to their config keys
+var configFlags = map[string]string{
+	"listen":            "listen",
+	"mode":              "mode",
+	"log-level":         "log_level",
+	"read-timeout":      "read_timeout",
+	"write-timeout":     "write_timeout",
+	"idle-timeout":      "idle_timeout",
+	"trash-retention":   "trash_retention",
+	"undo-depth":        "undo_depth",
+	"store":             "store",
+	"postgres-host":     "postgres.host",
+	"postgres-port":     "postgres.port",
+	"postgres-user":     "postgres.user",
+	"postgres-password": "postgres.password",
+	"postgres-dbname":   "postgres.dbname",
+	"postgres-sslmode":  "postgres.sslmode",
+	"sqlite-path":       "sqlite.path",
+	"urgency-priority":  "urgency.priority",
+	"urgency-due":       "urgency.due",
+	"urgency-age":       "urgency.age",
+	"urgency-tags":      "urgency.tags",
+}
+
+var logLevels = []string{"silent", "error", "warn", "info", "debug"}
+
+// AddConfigFlags registers the server flags on the flag set
+func AddConfigFlags(flags *pflag.FlagSet) {
+	flags.String("config", "", "config file, by default server.yaml or server.toml in . or ~/.config/todo")
+	flags.String("listen", "", "address to listen on, like :8080")
+	flags.String("mode", "", "prod or test")
+	flags.String("log-level", "", "one of "+strings.Join(logLevels, ", "))
+	flags.Duration("read-timeout", 0, "maximum duration for reading a request")
+	flags.Duration("write-timeout", 0, "maximum duration for writing a response")
+	flags.Duration("idle-timeout", 0, "maximum duration to keep an idle connection open")
+	flags.Duration("trash-retention", 0, "how long deleted todos are kept in the trash, 0 keeps them until it is emptied")
+	flags.Int("undo-depth", 0, "how many operations of a user can be undone")
+	flags.String("store", "", "postgres or sqlite")
+	flags.String("postgres-host", "", "postgres host")
+	flags.Int("postgres-port", 0, "postgres port")
+	flags.String("postgres-user", "", "postgres user")
+	flags.String("postgres-password", "", "postgres password")
+	flags.String("postgres-dbname", "", "postgres database name")
+	flags.String("postgres-sslmode", "", "postgres sslmode")
+	flags.String("sqlite-path", "", "sqlite database file")
+	flags.Float64("urgency-priority", 0, "weight of the priority in the urgency of todos")
+	flags.Float64("urgency-due", 0, "weight of the due date in the urgency of todos")
+	flags.Float64("urgency-age", 0, "weight of the age in the urgency of todos")
+	flags.Float64("urgency-tags", 0, "weight of the tags in the urgency of todos")
+}
+
+// LoadConfig layers the config file, the environment and the flags
+// registered by AddConfigFlags over the defaults and validates the result
+func LoadConfig(flags *pflag.FlagSet) (Config, error) {
+	v := viper.New()
+	for key, value := range configDefaults {
+		v.SetDefault(key, value)
+	}
+
+	v.SetEnvPrefix("TODO")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	for name, key := range configFlags {
+		if flag := flags.Lookup(name); flag != nil {
+			if err := v.BindPFlag(key, flag); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+
+	// an explicit config file has to exist, the default ones are optional
+	path, _ := flags.GetString("config")
+	if path == "" {
+		path = v.GetString("config")
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("server")
+		v.AddConfigPath(".")
+		v.AddConfigPath("$HOME/.config/todo")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if path != "" || !errors.As(err, &notFound) {
+			return Config{}, fmt.Errorf("could not read config: %w", err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return Config{}, fmt.Errorf("could not read config: %w", err)
+	}
+	if cfg.Postgres.DBName == "" {
+		cfg.Postgres.DBName = "todo_cli"
+		if cfg.Mode != "prod" {
+			cfg.Postgres.DBName = "todo_cli_test"
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate reports every invalid value of the config at once
+func (c Config) Validate() error {
+	var problems []string
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		problems = append(problems, fmt.Sprintf("listen %q is not a host:port address", c.Listen))
+	}
+	if c.Mode != "prod" && c.Mode != "test" {
+		problems = append(problems, fmt.Sprintf("mode %q is not prod or test", c.Mode))
+	}
+	if !contains(logLevels, c.LogLevel) {
+		problems = append(problems, fmt.Sprintf("log_level %q is not one of %s", c.LogLevel, strings.Join(logLevels, ", ")))
+	}
+	for name, timeout := range map[string]time.Duration{
+		"read_timeout":    c.ReadTimeout,
+		"write_timeout":   c.WriteTimeout,
+		"idle_timeout":    c.IdleTimeout,
+		"trash_retention": c.TrashRetention,
+	} {
+		if timeout < 0 {
+			problems = append(problems, fmt.Sprintf("%s %s is negative", name, timeout))
+		}
+	}
+	if c.UndoDepth < 1 {
+		problems = append(problems, fmt.Sprintf("undo_depth %d is not a positive number", c.UndoDepth))
+	}
+
+	switch c.Store {
+	case "postgres":
+		if c.Postgres.Host == "" {
+			problems = append(problems, "postgres.host is empty")
+		}
+		if c.Postgres.Port < 1 || c.Postgres.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("postgres.port %d is not a valid port", c.Postgres.Port))
+		}
+		if c.Postgres.User == "" || c.Postgres.Password == "" {
+			problems = append(problems, "postgres.user/postgres.password must be set (TODO_POSTGRES_USER, TODO_POSTGRES_PASSWORD, --postgres-user, --postgres-password or the config file)")
+		}
+	case "sqlite":
+		if c.SQLite.Path == "" {
+			problems = append(problems, "sqlite.path is empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("store %q is not postgres or sqlite", c.Store))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DSN builds the Postgres connection string, quoting the values
+func (c PostgresConfig) DSN() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	pairs := []string{
+		"host='" + quote.Replace(c.Host) + "'",
+		fmt.Sprintf("port=%d", c.Port),
+		"user='" + quote.Replace(c.User) + "'",
+		"password='" + quote.Replace(c.Password) + "'",
+		"dbname='" + quote.Replace(c.DBName) + "'",
+		"sslmode='" + quote.Replace(c.SSLMode) + "'",
+	}
+	return strings.Join(pairs, " ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"github.com/spf13/pflag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newConfigFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("server", pflag.ContinueOnError)
+	AddConfigFlags(flags)
+	assertRandomErr(t, flags.Parse(args))
+	return flags
+}
+
+func TestLoadConfig(t *testing.T) {
+	// keep config files of the machine out of the way
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("TODO_POSTGRES_USER", "todo")
+		t.Setenv("TODO_POSTGRES_PASSWORD", "secret")
+		cfg, err := LoadConfig(newConfigFlags(t))
+		assertRandomErr(t, err)
+
+		if cfg.Listen != ":8080" || cfg.Mode != "prod" || cfg.Store != "postgres" {
+			t.Errorf("unexpected defaults %#v", cfg)
+		}
+		if cfg.Postgres.DBName != "todo_cli" {
+			t.Errorf("expected the prod database, got %q", cfg.Postgres.DBName)
+		}
+		if cfg.Urgency != urgencyWeights {
+			t.Errorf("expected the default urgency weights, got %#v", cfg.Urgency)
+		}
+		if cfg.UndoDepth != undoDepth {
+			t.Errorf("expected the default undo depth, got %d", cfg.UndoDepth)
+		}
+		if cfg.Postgres.User != "todo" || cfg.Postgres.Password != "secret" {
+			t.Errorf("expected the credentials of the env, got %#v", cfg.Postgres)
+		}
+	})
+
+	t.Run("postgres needs credentials", func(t *testing.T) {
+		_, err := LoadConfig(newConfigFlags(t, "--postgres-user", "todo"))
+		if err == nil || !strings.Contains(err.Error(), "postgres.password must be set") {
+			t.Errorf("expected the missing password to be reported, got %v", err)
+		}
+	})
+
+	t.Run("file, env and flags take precedence in that order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.yaml")
+		content := "listen: \":9000\"\nread_timeout: 5s\nstore: sqlite\nsqlite:\n  path: file.db\npostgres:\n  host: db.internal\n"
+		assertRandomErr(t, os.WriteFile(path, []byte(content), 0600))
+		t.Setenv("TODO_SQLITE_PATH", "env.db")
+		t.Setenv("TODO_POSTGRES_HOST", "env.internal")
+
+		cfg, err := LoadConfig(newConfigFlags(t, "--config", path, "--postgres-host", "flag.internal"))
+		assertRandomErr(t, err)
+
+		if cfg.Listen != ":9000" || cfg.ReadTimeout != 5*time.Second || cfg.Store != "sqlite" {
+			t.Errorf("expected the values of the file, got %#v", cfg)
+		}
+		if cfg.SQLite.Path != "env.db" {
+			t.Errorf("expected the env to override the file, got %q", cfg.SQLite.Path)
+		}
+		if cfg.Postgres.Host != "flag.internal" {
+			t.Errorf("expected the flag to override the env, got %q", cfg.Postgres.Host)
+		}
+	})
+
+	t.Run("a missing explicit config file is an error", func(t *testing.T) {
+		_, err := LoadConfig(newConfigFlags(t, "--config", filepath.Join(t.TempDir(), "missing.yaml")))
+		if err == nil {
+			t.Error("expected an error for the missing file")
+		}
+	})
+
+	t.Run("every invalid value is reported", func(t *testing.T) {
+		t.Setenv("TODO_MODE", "staging")
+		_, err := LoadConfig(newConfigFlags(t, "--listen", "8080", "--postgres-port", "70000", "--log-level", "loud"))
+		if err == nil {
+			t.Fatal("expected a validation error")
+		}
+		for _, want := range []string{"listen", "mode", "log_level", "postgres.port"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected %q in the error, got %q", want, err)
+			}
+		}
+	})
+}
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := PostgresConfig{Host: "localhost", Port: 5432, User: "todo", Password: "it's secret", DBName: "todo_cli", SSLMode: "disable"}
+	want := `host='localhost' port=5432 user='todo' password='it\'s secret' dbname='todo_cli' sslmode='disable'`
+	if got := cfg.DSN(); got != want {
+		t.Errorf("wanted %s, got %s", want, got)
+	}
+}
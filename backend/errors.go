@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes are part of the API, clients match on them instead of the
+// messages so they must not change once released
+const (
+	CodeUnauthorized       = "unauthorized"
+	CodeInternal           = "internal_error"
+	CodeInvalidTodo        = "invalid_todo"
+	CodeInvalidTodoUpdate  = "invalid_todo_update"
+	CodeInvalidUser        = "invalid_user"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeUsernameTaken      = "username_taken"
+	CodeInvalidProject     = "invalid_project"
+	CodeInvalidProjectID   = "invalid_project_id"
+	CodeInvalidTags        = "invalid_tags"
+	CodeInvalidDue         = "invalid_due"
+	CodeInvalidPriority    = "invalid_priority"
+	CodeInvalidRepeat      = "invalid_repeat"
+	CodeInvalidParent      = "invalid_parent"
+	CodeInvalidSeries      = "invalid_series"
+	CodeInvalidRevert      = "invalid_revert"
+	CodeInvalidPatch       = "invalid_patch"
+	CodeInvalidPage        = "invalid_page"
+	CodeInvalidSearch      = "invalid_search"
+	CodeInvalidFilter      = "invalid_filter"
+	CodeTodoNotFound       = "todo_not_found"
+	CodeProjectNotFound    = "project_not_found"
+	CodeTagNotFound        = "tag_not_found"
+	CodeSessionNotFound    = "session_not_found"
+	CodeSeriesNotFound     = "series_not_found"
+	CodeRevisionNotFound   = "revision_not_found"
+	CodeOperationNotFound  = "operation_not_found"
+	CodeJournalConflict    = "journal_conflict"
+	CodePatchTestFailed    = "patch_test_failed"
+	CodeStaleTodo          = "stale_todo"
+)
+
+// APIError is the error of a failed request, it is sent as
+//
+//	{"error": {"code": "todo_not_found", "message": "invalid id"}}
+//
+// with optional details like the position of a filter syntax error
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ErrorResponse is the envelope of every error response
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// writeError sends an error response with the status, code and message
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIError(w, status, &APIError{Code: code, Message: message})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr *APIError) {
+	encodedResBody, _ := json.Marshal(ErrorResponse{Error: apiErr})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(encodedResBody)
+}
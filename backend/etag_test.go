@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestTodoETags(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	// sendConditional sends a request with the precondition headers
+	sendConditional := func(method, url, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := NewAuthRequest(method, url, bytes.NewReader([]byte(body)))
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		return SendRequest(req, TodoWithID)
+	}
+
+	res := SendAuthRequest("POST", "http://localhost:8080/todos", `{"text": "water the plants"}`, TodoWithoutID)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	var todo Todo
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+	if todo.Version != 1 || res.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected a new todo at version 1, got %d and %q", todo.Version, res.Header().Get("ETag"))
+	}
+	url := "http://localhost:8080/todos/" + strconv.Itoa(todo.ID)
+
+	t.Run("get answers If-None-Match", func(t *testing.T) {
+		res := sendConditional("GET", url, "", map[string]string{"If-None-Match": `"1"`})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotModified)
+		if res.Body.Len() != 0 {
+			t.Errorf("expected no body, got %s", res.Body.String())
+		}
+		res = sendConditional("GET", url, "", map[string]string{"If-None-Match": `"7", W/"8"`})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		if res.Header().Get("ETag") != `"1"` {
+			t.Errorf("expected the ETag of the todo, got %q", res.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("a matching If-Match saves the change", func(t *testing.T) {
+		res := sendConditional("PUT", url, `{"text": "water the plants on the balcony"}`, map[string]string{"If-Match": `"1"`})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		if res.Header().Get("ETag") != `"2"` {
+			t.Errorf("expected the version to move on, got %q", res.Header().Get("ETag"))
+		}
+		res = sendConditional("PATCH", url, `{"priority": "high"}`, map[string]string{"If-Match": "*", "Content-Type": MergePatchType})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("a stale If-Match is rejected", func(t *testing.T) {
+		for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+			res := sendConditional(method, url, `{"text": "water the cactus"}`, map[string]string{"If-Match": `"1"`, "Content-Type": MergePatchType})
+			assertStatusCode(t, res.Result().StatusCode, http.StatusPreconditionFailed)
+			assertAPIError(t, res, CodeStaleTodo)
+			if res.Header().Get("ETag") != `"3"` {
+				t.Errorf("expected the current ETag on %s, got %q", method, res.Header().Get("ETag"))
+			}
+		}
+		found, err := store.GetTodo(uid, strconv.Itoa(todo.ID))
+		assertRandomErr(t, err)
+		if found.Text != "water the plants on the balcony" {
+			t.Errorf("expected the todo to be unchanged, got %#v", found)
+		}
+	})
+
+	t.Run("saving a stale todo fails", func(t *testing.T) {
+		first, err := store.GetTodo(uid, strconv.Itoa(todo.ID))
+		assertRandomErr(t, err)
+		second := first
+		first.Text = "water the ferns"
+		assertRandomErr(t, store.SaveTodo(&first))
+		second.Text = "water the palms"
+		if err := store.SaveTodo(&second); !errors.Is(err, errStaleTodo) {
+			t.Errorf("expected the second save to be stale, got %v", err)
+		}
+		if second.Version != first.Version-1 {
+			t.Errorf("expected the version of the stale todo to stay, got %d", second.Version)
+		}
+	})
+}
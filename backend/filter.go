@@ -0,0 +1,378 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FilterError points at the position of a syntax error in a filter
+// expression, the position is the byte offset into the expression
+type FilterError struct {
+	Message  string
+	Position int
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type filterToken struct {
+	kind  tokenKind
+	text  string
+	start int
+}
+
+// filterOps are the comparison operators, longest first
+var filterOps = []string{"<=", ">=", "!=", ":", "~", "=", "<", ">"}
+
+// filterDate matches the start of a date, a date or timestamp is a single
+// word up to whitespace or ")" so the colons of the time are no operators
+var filterDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+func lexFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	i := 0
+	for i < len(input) {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{tokenRParen, ")", i})
+			i++
+		case c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(input) && input[i] != '"'; i++ {
+				if input[i] == '\\' && i+1 < len(input) {
+					i++
+				}
+				sb.WriteByte(input[i])
+			}
+			if i >= len(input) {
+				return nil, &FilterError{"unterminated string", start}
+			}
+			i++
+			tokens = append(tokens, filterToken{tokenString, sb.String(), start})
+		case filterDate.MatchString(input[i:]):
+			start := i
+			for i < len(input) && !unicode.IsSpace(rune(input[i])) && input[i] != ')' {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenWord, input[start:i], start})
+		default:
+			if op := matchFilterOp(input[i:]); op != "" {
+				tokens = append(tokens, filterToken{tokenOp, op, i})
+				i += len(op)
+				continue
+			}
+			if c == '!' {
+				return nil, &FilterError{`unexpected "!"`, i}
+			}
+			start := i
+			for i < len(input) && !isFilterDelimiter(input[i:]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenWord, input[start:i], start})
+		}
+	}
+
+	return append(tokens, filterToken{tokenEOF, "", len(input)}), nil
+}
+
+func matchFilterOp(input string) string {
+	for _, op := range filterOps {
+		if strings.HasPrefix(input, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isFilterDelimiter(input string) bool {
+	c := rune(input[0])
+	return unicode.IsSpace(c) || c == '(' || c == ')' || c == '"' || c == '!' || matchFilterOp(input) != ""
+}
+
+// filterClause is a parameterized SQL condition
+type filterClause struct {
+	sql  string
+	args []interface{}
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	uid    int
+	now    time.Time
+}
+
+// ParseFilter parses a filter expression like
+//
+//	status:open and (tag:work or project:infra) and due<2026-11-01 and text~"deploy"
+//
+// into a parameterized SQL condition on the todos of the user
+func ParseFilter(input string, uid int) (string, []interface{}, error) {
+	tokens, err := lexFilter(input)
+	if err != nil {
+		return "", nil, err
+	}
+	p := &filterParser{tokens: tokens, uid: uid, now: time.Now()}
+	if p.peek().kind == tokenEOF {
+		return "", nil, &FilterError{"empty filter", 0}
+	}
+
+	clause, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return "", nil, &FilterError{fmt.Sprintf("unexpected %q", tok.text), tok.start}
+	}
+
+	return clause.sql, clause.args, nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *filterParser) parseOr() (filterClause, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		left = joinClauses(left, "OR", right)
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterClause, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return left, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return right, err
+		}
+		left = joinClauses(left, "AND", right)
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterClause, error) {
+	if p.isKeyword("not") {
+		p.next()
+		clause, err := p.parseNot()
+		if err != nil {
+			return clause, err
+		}
+		return filterClause{"NOT (" + clause.sql + ")", clause.args}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (filterClause, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenLParen:
+		p.next()
+		clause, err := p.parseOr()
+		if err != nil {
+			return clause, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return clause, &FilterError{`expected ")"`, closing.start}
+		}
+		return filterClause{"(" + clause.sql + ")", clause.args}, nil
+	case tokenWord:
+		if p.isKeyword("and") || p.isKeyword("or") {
+			return filterClause{}, &FilterError{fmt.Sprintf("unexpected %q", tok.text), tok.start}
+		}
+		return p.parseTerm()
+	case tokenEOF:
+		return filterClause{}, &FilterError{"unexpected end of filter", tok.start}
+	default:
+		return filterClause{}, &FilterError{fmt.Sprintf("unexpected %q", tok.text), tok.start}
+	}
+}
+
+func (p *filterParser) parseTerm() (filterClause, error) {
+	field := p.next()
+	op := p.next()
+	if op.kind != tokenOp {
+		return filterClause{}, &FilterError{fmt.Sprintf("expected an operator after %q", field.text), op.start}
+	}
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return filterClause{}, &FilterError{fmt.Sprintf("expected a value after %q", op.text), value.start}
+	}
+
+	unsupported := &FilterError{fmt.Sprintf("operator %q is not supported for %s", op.text, field.text), op.start}
+	switch strings.ToLower(field.text) {
+	case "status":
+		if op.text != ":" && op.text != "=" {
+			return filterClause{}, unsupported
+		}
+		return p.statusClause(value)
+	case "tag":
+		if op.text != ":" && op.text != "=" {
+			return filterClause{}, unsupported
+		}
+		return filterClause{
+			"id IN (SELECT todo_tags.todo_id FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE tags.name = ?)",
+			[]interface{}{value.text},
+		}, nil
+	case "project":
+		if op.text != ":" && op.text != "=" {
+			return filterClause{}, unsupported
+		}
+		return filterClause{
+			"project_id IN (SELECT id FROM projects WHERE uid = ? AND (name = ? OR CAST(id AS TEXT) = ?))",
+			[]interface{}{p.uid, value.text, value.text},
+		}, nil
+	case "due":
+		return p.dueClause(op, value)
+	case "priority":
+		return p.priorityClause(op, value)
+	case "text":
+		switch op.text {
+		case ":", "=":
+			return filterClause{"text = ?", []interface{}{value.text}}, nil
+		case "!=":
+			return filterClause{"text <> ?", []interface{}{value.text}}, nil
+		case "~":
+			return filterClause{`LOWER(text) LIKE ? ESCAPE '\'`, []interface{}{"%" + escapeLike(strings.ToLower(value.text)) + "%"}}, nil
+		}
+		return filterClause{}, unsupported
+	default:
+		return filterClause{}, &FilterError{fmt.Sprintf("unknown field %q", field.text), field.start}
+	}
+}
+
+func (p *filterParser) statusClause(value filterToken) (filterClause, error) {
+	switch strings.ToLower(value.text) {
+	case "open":
+		return filterClause{"completed = ?", []interface{}{false}}, nil
+	case "done", "completed":
+		return filterClause{"completed = ?", []interface{}{true}}, nil
+	case "overdue":
+		return filterClause{"due < ? AND completed = ?", []interface{}{p.now, false}}, nil
+	}
+	return filterClause{}, &FilterError{fmt.Sprintf("unknown status %q, use open, done or overdue", value.text), value.start}
+}
+
+func (p *filterParser) dueClause(op, value filterToken) (filterClause, error) {
+	if strings.EqualFold(value.text, "none") {
+		switch op.text {
+		case ":", "=":
+			return filterClause{"due IS NULL", nil}, nil
+		case "!=":
+			return filterClause{"due IS NOT NULL", nil}, nil
+		}
+		return filterClause{}, &FilterError{fmt.Sprintf("operator %q is not supported for none", op.text), op.start}
+	}
+
+	// a plain date stands for the whole day
+	start, err := time.ParseInLocation("2006-01-02", value.text, time.Local)
+	end := start.AddDate(0, 0, 1)
+	if err != nil {
+		start, err = time.Parse(time.RFC3339, value.text)
+		end = start
+		if err != nil {
+			return filterClause{}, &FilterError{fmt.Sprintf("invalid date %q", value.text), value.start}
+		}
+	}
+
+	switch op.text {
+	case "<":
+		return filterClause{"due < ?", []interface{}{start}}, nil
+	case "<=":
+		if end.Equal(start) {
+			return filterClause{"due <= ?", []interface{}{start}}, nil
+		}
+		return filterClause{"due < ?", []interface{}{end}}, nil
+	case ">":
+		if end.Equal(start) {
+			return filterClause{"due > ?", []interface{}{start}}, nil
+		}
+		return filterClause{"due >= ?", []interface{}{end}}, nil
+	case ">=":
+		return filterClause{"due >= ?", []interface{}{start}}, nil
+	case ":", "=":
+		if end.Equal(start) {
+			return filterClause{"due = ?", []interface{}{start}}, nil
+		}
+		return filterClause{"due >= ? AND due < ?", []interface{}{start, end}}, nil
+	}
+	return filterClause{}, &FilterError{fmt.Sprintf("operator %q is not supported for due", op.text), op.start}
+}
+
+func (p *filterParser) priorityClause(op, value filterToken) (filterClause, error) {
+	priority, err := ParsePriority(value.text)
+	if err != nil {
+		return filterClause{}, &FilterError{fmt.Sprintf("unknown priority %q, use none, low, medium, high or 0 to 3", value.text), value.start}
+	}
+
+	switch op.text {
+	case ":", "=":
+		return filterClause{"priority = ?", []interface{}{priority}}, nil
+	case "!=":
+		return filterClause{"priority <> ?", []interface{}{priority}}, nil
+	case "<", "<=", ">", ">=":
+		return filterClause{"priority " + op.text + " ?", []interface{}{priority}}, nil
+	}
+	return filterClause{}, &FilterError{fmt.Sprintf("operator %q is not supported for priority", op.text), op.start}
+}
+
+func joinClauses(left filterClause, op string, right filterClause) filterClause {
+	return filterClause{
+		sql:  "(" + left.sql + ") " + op + " (" + right.sql + ")",
+		args: append(append([]interface{}{}, left.args...), right.args...),
+	}
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
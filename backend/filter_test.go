@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Run("valid expressions", func(t *testing.T) {
+		valid := []string{
+			`status:open`,
+			`status:open and (tag:work or project:infra) and due<2026-11-01 and text~"deploy"`,
+			`not tag:someday`,
+			`due:none or due>="2026-11-01T10:00:00Z"`,
+			`due<2026-11-01T10:00:00Z and (due>2026-10-01T10:00:00+02:00)`,
+			`priority>=medium and priority!=3`,
+		}
+		for _, input := range valid {
+			if _, _, err := ParseFilter(input, 1); err != nil {
+				t.Errorf("didn't expect an error for %#v, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("syntax errors point at the bad position", func(t *testing.T) {
+		invalid := map[string]int{
+			`status:open and`:      15,
+			`(tag:work`:            9,
+			`color:red`:            0,
+			`text~"deploy`:         5,
+			`tag<work`:             3,
+			`due<tomorrow`:         4,
+			`status:open tag:work`: 12,
+			`status:open and or`:   16,
+			`status:sleeping`:      7,
+			`priority:urgent`:      9,
+			`priority~high`:        8,
+		}
+		for input, position := range invalid {
+			_, _, err := ParseFilter(input, 1)
+			filterErr, ok := err.(*FilterError)
+			if !ok {
+				t.Errorf("expected a FilterError for %#v, got %v", input, err)
+				continue
+			}
+			if filterErr.Position != position {
+				t.Errorf("expected the error for %#v at %d, got %d (%s)", input, position, filterErr.Position, filterErr.Message)
+			}
+		}
+	})
+}
+
+func TestFilterTodos(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	createTaggedTodo(t, "deploy the api", "work")
+	createTaggedTodo(t, "deploy the blog", "home")
+	createTaggedTodo(t, "water plants", "work")
+
+	getFiltered := func(q string) *httptest.ResponseRecorder {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?q="+url.QueryEscape(q), nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+		return res
+	}
+
+	t.Run("matching todos", func(t *testing.T) {
+		res := getFiltered(`status:open and tag:work and text~"DEPLOY"`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var todos []Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		if len(todos) != 1 || todos[0].Text != "deploy the api" {
+			t.Errorf("expected only the work deploy todo, got %#v", todos)
+		}
+	})
+
+	t.Run("structured syntax error", func(t *testing.T) {
+		res := getFiltered(`tag:work and (`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+
+		apiErr := assertAPIError(t, res, CodeInvalidFilter)
+		details, _ := apiErr.Details.(map[string]interface{})
+		if details["position"] != float64(14) || apiErr.Message == "" {
+			t.Errorf("expected an error at position 14, got %#v", apiErr)
+		}
+	})
+}
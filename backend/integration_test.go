@@ -85,7 +85,7 @@ func updateTodo(id int, reqBody interface{}) {
 	encodedReqBody, _ := json.Marshal(reqBody)
 	TodoWithID(
 		httptest.NewRecorder(),
-		httptest.NewRequest(
+		NewAuthRequest(
 			"PUT",
 			"http://localhost:8080/todos/"+strconv.Itoa(id),
 			bytes.NewReader(encodedReqBody),
@@ -97,7 +97,7 @@ func getTodo(id int) map[string]interface{} {
 	res := httptest.NewRecorder()
 	TodoWithID(
 		res,
-		httptest.NewRequest(
+		NewAuthRequest(
 			"GET",
 			"http://localhost:8080/todos/"+strconv.Itoa(id),
 			nil,
@@ -113,7 +113,7 @@ func getTodo(id int) map[string]interface{} {
 func deleteTodo(id int) {
 	TodoWithoutID(
 		httptest.NewRecorder(),
-		httptest.NewRequest(
+		NewAuthRequest(
 			"DELETE",
 			"http://localhost:8080/todos/"+strconv.Itoa(id),
 			nil,
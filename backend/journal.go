@@ -0,0 +1,288 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// undoDepth is how many operations of a user the journal keeps, it is set
+// from the config by StartServer
+var undoDepth = 50
+
+// Kinds of the operations in the journal
+const (
+	OperationCreate   = "create"
+	OperationUpdate   = "update"
+	OperationComplete = "complete"
+	OperationDelete   = "delete"
+)
+
+// Operation is an entry of the undo journal of a user. Before and After
+// hold the fields an update changed as JSON objects written like a request
+// body. For a delete Before lists the children which moved up to the
+// parent of the todo and After whether its children were deleted with it.
+type Operation struct {
+	ID     int    `gorm:"primaryKey" json:"id"`
+	UserID int    `gorm:"column:uid" json:"uid"`
+	Kind   string `json:"kind"`
+	TodoID int    `json:"todo_id"`
+	// NextID is the todo created by completing a repeating todo
+	NextID    *int          `json:"next_id"`
+	Before    RevisionValue `gorm:"column:before_fields" json:"before"`
+	After     RevisionValue `gorm:"column:after_fields" json:"after"`
+	Undone    bool          `json:"undone"`
+	CreatedAt time.Time     `json:"created_at"`
+	// Todo is the todo after undoing or redoing the operation
+	Todo *Todo `gorm:"-" json:"todo,omitempty"`
+}
+
+// errJournalConflict is returned when the todo of an operation changed in
+// a way the journal doesn't know about, like an edit from another client
+var errJournalConflict = errors.New(ErrJournalConflict)
+
+// deleteFields are the Before and After of a delete
+type deleteFields struct {
+	Children       []int `json:"children,omitempty"`
+	DeleteChildren bool  `json:"delete_children,omitempty"`
+}
+
+func journalCreate(todo Todo) error {
+	return store.AddOperation(&Operation{UserID: todo.UserID, Kind: OperationCreate, TodoID: todo.ID}, undoDepth)
+}
+
+// journalUpdate records the fields which differ between the todo before
+// and after an update, nothing is recorded when no field changed
+func journalUpdate(uid int, before, after Todo, next *Todo) error {
+	revisions := diffTodo(before, after, Session{UserID: uid})
+	if len(revisions) == 0 {
+		return nil
+	}
+	oldFields, newFields := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	for _, revision := range revisions {
+		oldFields[revision.Field] = json.RawMessage(revision.OldValue)
+		newFields[revision.Field] = json.RawMessage(revision.NewValue)
+	}
+	encodedBefore, _ := json.Marshal(oldFields)
+	encodedAfter, _ := json.Marshal(newFields)
+
+	op := Operation{
+		UserID: uid,
+		Kind:   OperationUpdate,
+		TodoID: after.ID,
+		Before: RevisionValue(encodedBefore),
+		After:  RevisionValue(encodedAfter),
+	}
+	if after.Completed && !before.Completed {
+		op.Kind = OperationComplete
+	}
+	if next != nil {
+		op.NextID = &next.ID
+	}
+	return store.AddOperation(&op, undoDepth)
+}
+
+// journalDelete records the deletion of the todo, children are the ids of
+// the todos which moved up to its parent
+func journalDelete(todo Todo, children []int, deleteChildren bool) error {
+	encodedBefore, _ := json.Marshal(deleteFields{Children: children})
+	encodedAfter, _ := json.Marshal(deleteFields{DeleteChildren: deleteChildren})
+	return store.AddOperation(&Operation{
+		UserID: todo.UserID,
+		Kind:   OperationDelete,
+		TodoID: todo.ID,
+		Before: RevisionValue(encodedBefore),
+		After:  RevisionValue(encodedAfter),
+	}, undoDepth)
+}
+
+// HandleUndo reverses the most recent operation of the user which isn't
+// undone yet
+func HandleUndo(w http.ResponseWriter, r *http.Request) {
+	handleJournal(w, r, true)
+}
+
+// HandleRedo applies the most recently undone operation again
+func HandleRedo(w http.ResponseWriter, r *http.Request) {
+	handleJournal(w, r, false)
+}
+
+// handleJournal undoes or redoes an operation. The operation is claimed
+// before it is replayed so concurrent requests never replay it twice, and
+// it is released again when its todo changed in the meantime.
+func handleJournal(w http.ResponseWriter, r *http.Request, undo bool) {
+	session, err := getSession(w, r)
+	if err != nil {
+		return
+	}
+
+	op, err := store.PeekOperation(session.UserID, undo)
+	if !assertServerError(err, w) {
+		return
+	}
+	if op.ID == 0 {
+		message := ErrNothingToUndo
+		if !undo {
+			message = ErrNothingToRedo
+		}
+		writeError(w, http.StatusNotFound, CodeOperationNotFound, message)
+		return
+	}
+	claimed, err := store.MarkOperation(op, undo)
+	if !assertServerError(err, w) {
+		return
+	}
+	if !claimed {
+		writeError(w, http.StatusConflict, CodeJournalConflict, ErrJournalBusy)
+		return
+	}
+
+	todo, err := replayOperation(session, op, undo)
+	if err != nil {
+		if _, releaseErr := store.MarkOperation(op, !undo); releaseErr != nil {
+			goLogger.Error(releaseErr)
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) || errors.Is(err, errJournalConflict) || errors.Is(err, errStaleTodo) {
+			writeError(w, http.StatusConflict, CodeJournalConflict, err.Error())
+			return
+		}
+		assertServerError(err, w)
+		return
+	}
+
+	todo.Urgency = urgencyWeights.Urgency(todo, time.Now())
+	if !assertServerError(setProgress(&todo), w) {
+		return
+	}
+	op.Undone = undo
+	op.Todo = &todo
+	encodedResBody, _ := json.Marshal(op)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// replayOperation undoes or redoes the operation, the todo has to be in the
+// state the operation left it in or it fails with errJournalConflict
+func replayOperation(session Session, op Operation, undo bool) (Todo, error) {
+	uid := session.UserID
+	switch op.Kind {
+	case OperationCreate:
+		if undo {
+			return trashTodo(uid, op.TodoID, false)
+		}
+		return restoreTodo(uid, op.TodoID)
+
+	case OperationDelete:
+		if !undo {
+			var after deleteFields
+			if err := json.Unmarshal([]byte(op.After), &after); err != nil {
+				return Todo{}, err
+			}
+			return trashTodo(uid, op.TodoID, after.DeleteChildren)
+		}
+		var before deleteFields
+		if err := json.Unmarshal([]byte(op.Before), &before); err != nil {
+			return Todo{}, err
+		}
+		todo, err := restoreTodo(uid, op.TodoID)
+		if err != nil {
+			return todo, err
+		}
+		// move the children back unless they moved elsewhere since
+		for _, id := range before.Children {
+			child, err := store.GetTodo(uid, strconv.Itoa(id))
+			if err != nil {
+				return todo, err
+			}
+			if child.ID == 0 || !sameID(child.ParentID, todo.ParentID) {
+				continue
+			}
+			child.ParentID = &todo.ID
+			if err := store.SaveTodo(&child); err != nil {
+				return todo, err
+			}
+		}
+		return todo, nil
+	}
+
+	expected, apply := op.After, op.Before
+	if !undo {
+		expected, apply = op.Before, op.After
+	}
+	var expectedFields map[string]json.RawMessage
+	var decodedReqBody map[string]interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedFields); err != nil {
+		return Todo{}, err
+	}
+	if err := json.Unmarshal([]byte(apply), &decodedReqBody); err != nil {
+		return Todo{}, err
+	}
+
+	todo, err := store.GetTodo(uid, strconv.Itoa(op.TodoID))
+	if err != nil {
+		return todo, err
+	}
+	if todo.ID == 0 {
+		return todo, errJournalConflict
+	}
+	for _, field := range revisionFields {
+		value, ok := expectedFields[field.name]
+		if !ok {
+			continue
+		}
+		current, _ := json.Marshal(field.value(todo))
+		if string(current) != string(value) {
+			return todo, errJournalConflict
+		}
+	}
+
+	// the next todo of a completed repeating todo goes with the completion
+	if op.NextID != nil {
+		if undo {
+			_, err = trashTodo(uid, *op.NextID, false)
+		} else {
+			_, err = restoreTodo(uid, *op.NextID)
+		}
+		if err != nil {
+			return todo, err
+		}
+	}
+	_, err = changeTodo(session, &todo, decodedReqBody)
+	return todo, err
+}
+
+// trashTodo moves the todo with the id to the trash, it has to be out of
+// the trash
+func trashTodo(uid, id int, deleteChildren bool) (Todo, error) {
+	todo, err := store.GetTodo(uid, strconv.Itoa(id))
+	if err != nil {
+		return todo, err
+	}
+	if todo.ID == 0 {
+		return todo, errJournalConflict
+	}
+	return todo, store.DeleteTodo(&todo, deleteChildren)
+}
+
+// restoreTodo takes the todo with the id out of the trash, it has to be in
+// the trash
+func restoreTodo(uid, id int) (Todo, error) {
+	todo, err := store.GetDeletedTodo(uid, strconv.Itoa(id))
+	if err != nil {
+		return todo, err
+	}
+	if todo.ID == 0 {
+		return todo, errJournalConflict
+	}
+	return todo, store.RestoreTodo(&todo)
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUndoRedo(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	update := func(todo Todo, body string) {
+		res := SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID), body, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	}
+	replay := func(t *testing.T, handler http.HandlerFunc, kind string) Todo {
+		t.Helper()
+		res := SendAuthRequest("POST", "http://localhost:8080/undo", "", handler)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		var op Operation
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &op))
+		if op.Kind != kind || op.Todo == nil {
+			t.Fatalf("expected a %s operation with its todo, got %#v", kind, op)
+		}
+		return *op.Todo
+	}
+	get := func(todo Todo) Todo {
+		found, err := store.GetTodo(uid, strconv.Itoa(todo.ID))
+		assertRandomErr(t, err)
+		return found
+	}
+
+	t.Run("undo and redo the last operations", func(t *testing.T) {
+		todo := CreateTestTodo(t, `{"text": "renew passport"}`)
+		update(todo, `{"text": "renew passport and id card", "tags": ["admin"]}`)
+		update(todo, `{"completed": true}`)
+
+		if undone := replay(t, HandleUndo, OperationComplete); undone.Completed {
+			t.Errorf("expected the todo to be open again, got %#v", undone)
+		}
+		undone := replay(t, HandleUndo, OperationUpdate)
+		if undone.Text != "renew passport" || len(get(todo).Tags) != 0 {
+			t.Errorf("expected the text and tags to be undone, got %#v", undone)
+		}
+		replay(t, HandleUndo, OperationCreate)
+		if get(todo).ID != 0 {
+			t.Errorf("expected the created todo to be in the trash")
+		}
+
+		replay(t, HandleRedo, OperationCreate)
+		redone := replay(t, HandleRedo, OperationUpdate)
+		if redone.Text != "renew passport and id card" || len(get(todo).Tags) != 1 {
+			t.Errorf("expected the update to be redone, got %#v", redone)
+		}
+
+		// a new operation can't be followed by a redo
+		update(todo, `{"priority": "high"}`)
+		res := SendAuthRequest("POST", "http://localhost:8080/redo", "", HandleRedo)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeOperationNotFound)
+	})
+
+	t.Run("undo a delete moves the children back", func(t *testing.T) {
+		parent := CreateTestTodo(t, `{"text": "garden"}`)
+		child := CreateTestTodo(t, `{"text": "mow the lawn", "parent_id": `+strconv.Itoa(parent.ID)+`}`)
+		res := SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(parent.ID), "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		replay(t, HandleUndo, OperationDelete)
+		if got := get(child); got.ParentID == nil || *got.ParentID != parent.ID {
+			t.Errorf("expected the child below its parent again, got %#v", got)
+		}
+	})
+
+	t.Run("undo completing a repeating todo", func(t *testing.T) {
+		due := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		todo := CreateTestTodo(t, `{"text": "pay rent", "due": "`+due+`", "repeat": "FREQ=MONTHLY"}`)
+		update(todo, `{"completed": true}`)
+
+		undone := replay(t, HandleUndo, OperationComplete)
+		if undone.Completed || undone.Repeat == nil {
+			t.Errorf("expected the todo to repeat again, got %#v", undone)
+		}
+		if series, _ := store.ListSeriesTodos(uid, todo.ID); len(series) != 1 {
+			t.Errorf("expected the next todo to be in the trash, got %#v", series)
+		}
+		replay(t, HandleRedo, OperationComplete)
+		if series, _ := store.ListSeriesTodos(uid, todo.ID); len(series) != 2 || series[1].Repeat == nil {
+			t.Errorf("expected the next todo back, got %#v", series)
+		}
+	})
+
+	t.Run("a todo changed elsewhere is not undone", func(t *testing.T) {
+		todo := CreateTestTodo(t, `{"text": "book flights"}`)
+		update(todo, `{"text": "book flights to Rome"}`)
+		changed := get(todo)
+		changed.Text = "book trains to Rome"
+		assertRandomErr(t, store.SaveTodo(&changed))
+
+		res := SendAuthRequest("POST", "http://localhost:8080/undo", "", HandleUndo)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusConflict)
+		assertAPIError(t, res, CodeJournalConflict)
+		if get(todo).Text != "book trains to Rome" {
+			t.Errorf("expected the todo to be left alone")
+		}
+		// the operation stays in the journal
+		op, err := store.PeekOperation(uid, true)
+		assertRandomErr(t, err)
+		if op.TodoID != todo.ID || op.Kind != OperationUpdate {
+			t.Errorf("expected the update to stay undoable, got %#v", op)
+		}
+	})
+
+	t.Run("an operation is claimed once", func(t *testing.T) {
+		op, err := store.PeekOperation(uid, true)
+		assertRandomErr(t, err)
+		first, err := store.MarkOperation(op, true)
+		assertRandomErr(t, err)
+		second, err := store.MarkOperation(op, true)
+		assertRandomErr(t, err)
+		if !first || second {
+			t.Errorf("expected only the first claim to succeed, got %v and %v", first, second)
+		}
+		_, err = store.MarkOperation(op, false)
+		assertRandomErr(t, err)
+	})
+
+	t.Run("the journal keeps the last operations", func(t *testing.T) {
+		defer func(depth int) { undoDepth = depth }(undoDepth)
+		undoDepth = 2
+		todo := CreateTestTodo(t, `{"text": "a"}`)
+		update(todo, `{"text": "b"}`)
+		update(todo, `{"text": "c"}`)
+
+		replay(t, HandleUndo, OperationUpdate)
+		replay(t, HandleUndo, OperationUpdate)
+		res := SendAuthRequest("POST", "http://localhost:8080/undo", "", HandleUndo)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeOperationNotFound)
+	})
+}
@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// the handlers are called without the mux router in tests
+	mode = "test"
+	code := m.Run()
+	// remove the sqlite files of the test stores
+	if testDir != "" {
+		_ = os.RemoveAll(testDir)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,241 @@
+package backend
+
+import (
+	"embed"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema of every dialect as numbered pairs of
+// files, like 0001_create_users_and_todos.up.sql and its .down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is one step of the schema, Up applies it and Down reverts it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration has been applied and when
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaVersion records an applied migration
+type schemaVersion struct {
+	Version   int `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+func (schemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// addColumnIfNotExists matches ALTER TABLE ... ADD COLUMN IF NOT EXISTS,
+// which sqlite does not support
+var addColumnIfNotExists = regexp.MustCompile(`(?i)ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)([^;]*);`)
+
+// loadMigrations reads the migrations of the dialect ordered by version
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction := strings.TrimSuffix(name, ".sql"), ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base, direction = strings.TrimSuffix(base, ".up"), "up"
+		case strings.HasSuffix(base, ".down"):
+			base, direction = strings.TrimSuffix(base, ".down"), "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", name)
+		}
+		prefix, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+
+		sql, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// appliedVersions returns the applied migrations, creating the
+// schema_version table on first use
+func (s gormStore) appliedVersions() (map[int]time.Time, error) {
+	if err := s.db.AutoMigrate(&schemaVersion{}); err != nil {
+		return nil, err
+	}
+	var versions []schemaVersion
+	if err := s.db.Find(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	applied := map[int]time.Time{}
+	for _, v := range versions {
+		applied[v.Version] = v.AppliedAt
+	}
+	return applied, nil
+}
+
+func (s gormStore) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+func (s gormStore) MigrateUp() error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range status {
+		if m.AppliedAt != nil {
+			continue
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := s.execMigration(tx, m.Up); err != nil {
+				return err
+			}
+			return tx.Create(&schemaVersion{Version: m.Version, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// execMigration runs the sql of a migration. On sqlite the statements
+// adding a column if it does not exist run on their own, after checking
+// for the column, so databases from before the migrations can catch up.
+func (s gormStore) execMigration(tx *gorm.DB, sql string) error {
+	for s.dialect == "sqlite" {
+		loc := addColumnIfNotExists.FindStringSubmatchIndex(sql)
+		if loc == nil {
+			break
+		}
+		if err := execSQL(tx, sql[:loc[0]]); err != nil {
+			return err
+		}
+		table, column := sql[loc[2]:loc[3]], sql[loc[4]:loc[5]]
+		if !tx.Migrator().HasColumn(table, column) {
+			if err := execSQL(tx, "ALTER TABLE "+table+" ADD COLUMN "+column+sql[loc[6]:loc[7]]); err != nil {
+				return err
+			}
+		}
+		sql = sql[loc[1]:]
+	}
+	return execSQL(tx, sql)
+}
+
+// execSQL runs the statements, leaving out the ones that are only blank
+func execSQL(tx *gorm.DB, sql string) error {
+	if strings.TrimSpace(sql) == "" {
+		return nil
+	}
+	return tx.Exec(sql).Error
+}
+
+func (s gormStore) MigrateDown(steps int) error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	// revert the latest applied migrations first
+	for i := len(status) - 1; i >= 0 && steps > 0; i-- {
+		m := status[i]
+		if m.AppliedAt == nil {
+			continue
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaVersion{Version: m.Version}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		steps--
+	}
+	return nil
+}
+
+// CheckSchema returns an error when the store has pending migrations
+func CheckSchema(s Store) error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, m := range status {
+		if m.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", m.Version, m.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("the database schema is behind, pending migrations: %s; run `todo server migrate up`", strings.Join(pending, ", "))
+	}
+	return nil
+}
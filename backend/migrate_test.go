@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"fmt"
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	cleanTestEnvironment()
+	defer cleanTestEnvironment()
+
+	t.Run("a migrated store passes the schema check", func(t *testing.T) {
+		assertRandomErr(t, CheckSchema(store))
+	})
+
+	t.Run("status lists every migration as applied", func(t *testing.T) {
+		status, err := store.MigrationStatus()
+		assertRandomErr(t, err)
+
+		if len(status) == 0 {
+			t.Fatal("expected some migrations")
+		}
+		for i, m := range status {
+			if m.AppliedAt == nil {
+				t.Errorf("expected migration %d to be applied", m.Version)
+			}
+			if i > 0 && status[i-1].Version >= m.Version {
+				t.Errorf("migrations out of order, %d before %d", status[i-1].Version, m.Version)
+			}
+		}
+	})
+
+	t.Run("down reverts the latest migration and up applies it again", func(t *testing.T) {
+		assertRandomErr(t, store.MigrateDown(1))
+		if err := CheckSchema(store); err == nil {
+			t.Error("expected the schema check to fail with a pending migration")
+		}
+
+		assertRandomErr(t, store.MigrateUp())
+		assertRandomErr(t, CheckSchema(store))
+	})
+
+	t.Run("existing data survives reverting and reapplying the later migrations", func(t *testing.T) {
+		user := User{Uname: "adnan", Pass: "badshah"}
+		assertRandomErr(t, store.CreateUser(&user))
+		todo := Todo{Text: "survive", UserID: user.ID}
+		assertRandomErr(t, store.CreateTodo(&todo))
+
+		status, err := store.MigrationStatus()
+		assertRandomErr(t, err)
+		assertRandomErr(t, store.MigrateDown(len(status)-1))
+		assertRandomErr(t, store.MigrateUp())
+
+		got, err := store.GetTodo(user.ID, strconv.Itoa(todo.ID))
+		assertRandomErr(t, err)
+		if got.Text != todo.Text {
+			t.Errorf("expected todo %q to survive, got %#v", todo.Text, got)
+		}
+	})
+
+	t.Run("databases from before the migrations catch up", func(t *testing.T) {
+		schemas := map[string]string{
+			// the tables of the first versions had no completed column
+			"baseline": `
+				CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, uname TEXT NOT NULL, pass TEXT NOT NULL);
+				CREATE TABLE todos (id INTEGER PRIMARY KEY AUTOINCREMENT, text TEXT NOT NULL, uid INTEGER NOT NULL);`,
+			// the tables created by AutoMigrate before the migrations
+			"automigrate": `
+				CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, uname TEXT, pass TEXT);
+				CREATE TABLE sessions (token TEXT PRIMARY KEY, uid INTEGER);
+				CREATE TABLE projects (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, uid INTEGER);
+				CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, uid INTEGER);
+				CREATE TABLE todo_tags (todo_id INTEGER, tag_id INTEGER, PRIMARY KEY (todo_id, tag_id));
+				CREATE TABLE todos (id INTEGER PRIMARY KEY AUTOINCREMENT, text TEXT, uid INTEGER, completed NUMERIC,
+					completed_at DATETIME, due DATETIME, project_id INTEGER);`,
+		}
+		for name, schema := range schemas {
+			testDBCount++
+			s, err := NewSQLiteStore(filepath.Join(testDir, fmt.Sprintf("test-%d.db", testDBCount)), logger.Silent)
+			assertRandomErr(t, err)
+			assertRandomErr(t, s.db.Exec(schema).Error)
+			assertRandomErr(t, s.db.Exec("INSERT INTO users (uname, pass) VALUES ('adnan', 'badshah')").Error)
+			assertRandomErr(t, s.db.Exec("INSERT INTO todos (text, uid) VALUES ('survive', 1)").Error)
+
+			if err := s.MigrateUp(); err != nil {
+				t.Fatalf("expected the %s database to migrate, got %v", name, err)
+			}
+			todo, err := s.GetTodo(1, "1")
+			assertRandomErr(t, err)
+			todo.Completed = true
+			assertRandomErr(t, s.SaveTodo(&todo))
+			if got, err := s.GetTodo(1, "1"); err != nil || got.Text != "survive" || !got.Completed {
+				t.Errorf("expected the todo of the %s database to be completed, got %#v and %v", name, got, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS users (
+    id    BIGSERIAL PRIMARY KEY,
+    uname TEXT NOT NULL,
+    pass  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id        BIGSERIAL PRIMARY KEY,
+    text      TEXT NOT NULL,
+    uid       BIGINT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- databases from before the migrations have todos without a completed column
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS completed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    token TEXT PRIMARY KEY,
+    uid   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS todos_due_idx ON todos (uid, due);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id   BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    uid  BIGINT NOT NULL,
+    UNIQUE (uid, name)
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id BIGINT NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    tag_id  BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id   BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    uid  BIGINT NOT NULL
+);
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id BIGINT REFERENCES projects (id);
@@ -0,0 +1,3 @@
+DROP INDEX todos_search_idx;
+
+ALTER TABLE todos DROP COLUMN search;
@@ -0,0 +1,11 @@
+DROP INDEX sessions_uid_idx;
+
+ALTER TABLE sessions DROP COLUMN client_name;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN last_used_at;
+ALTER TABLE sessions DROP COLUMN created_at;
+
+ALTER TABLE sessions DROP CONSTRAINT sessions_token_key;
+ALTER TABLE sessions DROP CONSTRAINT sessions_pkey;
+ALTER TABLE sessions ADD PRIMARY KEY (token);
+ALTER TABLE sessions DROP COLUMN id;
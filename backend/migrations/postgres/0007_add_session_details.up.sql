@@ -0,0 +1,11 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id BIGSERIAL;
+ALTER TABLE sessions DROP CONSTRAINT sessions_pkey;
+ALTER TABLE sessions ADD PRIMARY KEY (id);
+ALTER TABLE sessions ADD CONSTRAINT sessions_token_key UNIQUE (token);
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS client_name TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS sessions_uid_idx ON sessions (uid);
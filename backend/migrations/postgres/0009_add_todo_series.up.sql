@@ -0,0 +1,6 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS repeat TEXT;
+-- the id of the first todo of the series, without a foreign key so the
+-- series outlives it
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS series_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS todos_series_idx ON todos (uid, series_id);
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES todos (id);
+
+CREATE INDEX IF NOT EXISTS todos_parent_idx ON todos (parent_id);
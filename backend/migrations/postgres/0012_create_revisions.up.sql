@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    todo_id    BIGINT NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    uid        BIGINT NOT NULL,
+    client     TEXT NOT NULL DEFAULT '',
+    field      TEXT NOT NULL,
+    old_value  TEXT NOT NULL,
+    new_value  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS revisions_todo_idx ON revisions (todo_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS operations (
+    id            BIGSERIAL PRIMARY KEY,
+    uid           BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind          TEXT NOT NULL,
+    todo_id       BIGINT NOT NULL,
+    next_id       BIGINT,
+    before_fields TEXT NOT NULL DEFAULT '',
+    after_fields  TEXT NOT NULL DEFAULT '',
+    undone        BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS operations_uid_idx ON operations (uid, undone);
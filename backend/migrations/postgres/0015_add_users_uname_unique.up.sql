@@ -0,0 +1,3 @@
+-- a login has to find a single user, existing duplicates have to be
+-- renamed before this migration
+CREATE UNIQUE INDEX IF NOT EXISTS users_uname_idx ON users (uname);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS users (
+    id    INTEGER PRIMARY KEY AUTOINCREMENT,
+    uname TEXT NOT NULL,
+    pass  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    text      TEXT NOT NULL,
+    uid       INTEGER NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- databases from before the migrations have todos without a completed column
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS completed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX todos_due_idx;
+
+ALTER TABLE todos DROP COLUMN due;
+ALTER TABLE todos DROP COLUMN completed_at;
@@ -0,0 +1,4 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS completed_at DATETIME;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due DATETIME;
+
+CREATE INDEX IF NOT EXISTS todos_due_idx ON todos (uid, due);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    uid  INTEGER NOT NULL
+);
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects (id);
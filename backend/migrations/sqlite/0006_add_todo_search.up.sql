@@ -0,0 +1 @@
+-- sqlite has no full-text column, todos are searched with LIKE
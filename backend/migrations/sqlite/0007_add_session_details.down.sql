@@ -0,0 +1,8 @@
+CREATE TABLE sessions_old (
+    token TEXT PRIMARY KEY,
+    uid   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+INSERT INTO sessions_old (token, uid) SELECT token, uid FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_old RENAME TO sessions;
@@ -0,0 +1,16 @@
+-- sqlite cannot change the primary key in place, rebuild the table
+CREATE TABLE sessions_new (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    token        TEXT NOT NULL UNIQUE,
+    uid          INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_agent   TEXT NOT NULL DEFAULT '',
+    client_name  TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO sessions_new (token, uid) SELECT token, uid FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+
+CREATE INDEX IF NOT EXISTS sessions_uid_idx ON sessions (uid);
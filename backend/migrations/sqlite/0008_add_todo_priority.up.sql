@@ -0,0 +1,5 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+-- the age of a todo counts towards its urgency, existing todos start now,
+-- sqlite does not allow CURRENT_TIMESTAMP as default of a new column
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS created_at DATETIME;
+UPDATE todos SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
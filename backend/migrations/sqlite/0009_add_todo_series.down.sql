@@ -0,0 +1,4 @@
+DROP INDEX todos_series_idx;
+
+ALTER TABLE todos DROP COLUMN series_id;
+ALTER TABLE todos DROP COLUMN repeat;
@@ -0,0 +1,8 @@
+-- the todos in the trash would show up again
+DELETE FROM todo_tags WHERE todo_id IN (SELECT id FROM todos WHERE deleted_at IS NOT NULL);
+UPDATE todos SET parent_id = NULL WHERE parent_id IN (SELECT id FROM todos WHERE deleted_at IS NOT NULL);
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+
+DROP INDEX todos_deleted_at_idx;
+
+ALTER TABLE todos DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS todos_deleted_at_idx ON todos (deleted_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS revisions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    todo_id    INTEGER NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    uid        INTEGER NOT NULL,
+    client     TEXT NOT NULL DEFAULT '',
+    field      TEXT NOT NULL,
+    old_value  TEXT NOT NULL,
+    new_value  TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS revisions_todo_idx ON revisions (todo_id);
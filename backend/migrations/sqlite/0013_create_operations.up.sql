@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS operations (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    uid           INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind          TEXT NOT NULL,
+    todo_id       INTEGER NOT NULL,
+    next_id       INTEGER,
+    before_fields TEXT NOT NULL DEFAULT '',
+    after_fields  TEXT NOT NULL DEFAULT '',
+    undone        BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS operations_uid_idx ON operations (uid, undone);
@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+type todoSort struct {
+	column   string
+	nullable bool
+	// computed sorts have no column, the todos are sorted after fetching
+	// them and are in descending order unless asked otherwise
+	computed bool
+}
+
+// todoSorts maps the sort query param to the todo columns,
+// nullable columns are sorted last regardless of direction
+var todoSorts = map[string]todoSort{
+	"created":  {column: "id"},
+	"due":      {column: "due", nullable: true},
+	"text":     {column: "text"},
+	"priority": {column: "priority"},
+	"urgency":  {computed: true},
+}
+
+// cursor points at the last todo of a page, it holds the value of the
+// sorted column and the id as tie breaker
+type cursor struct {
+	Value interface{} `json:"v"`
+	ID    int         `json:"id"`
+}
+
+// TodoPage selects a sorted page of todos
+type TodoPage struct {
+	limit int
+	sort  string
+	desc  bool
+	after *cursor
+}
+
+// parseTodoPage reads the limit, after, sort and order query params
+func parseTodoPage(r *http.Request) (TodoPage, error) {
+	params := r.URL.Query()
+	page := TodoPage{limit: defaultPageLimit, sort: "created"}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, errors.New(ErrInvalidPage)
+		}
+		page.limit = limit
+	}
+	if value := params.Get("sort"); value != "" {
+		if _, ok := todoSorts[value]; !ok {
+			return page, errors.New(ErrInvalidPage)
+		}
+		page.sort = value
+	}
+	switch params.Get("order") {
+	case "":
+		page.desc = todoSorts[page.sort].computed
+	case "asc":
+	case "desc":
+		page.desc = true
+	default:
+		return page, errors.New(ErrInvalidPage)
+	}
+	if value := params.Get("after"); value != "" {
+		after, err := decodeCursor(value, page.sort)
+		if err != nil {
+			return page, errors.New(ErrInvalidPage)
+		}
+		page.after = after
+	}
+
+	return page, nil
+}
+
+// find fetches the page of todos matched by query, along with the cursor
+// of the next page which is empty on the last page
+func (p TodoPage) find(query *gorm.DB) ([]Todo, string, error) {
+	sort := todoSorts[p.sort]
+	if sort.computed {
+		return p.findComputed(query)
+	}
+	dir, op := "ASC", ">"
+	if p.desc {
+		dir, op = "DESC", "<"
+	}
+
+	if p.after != nil {
+		switch {
+		case sort.column == "id":
+			query = query.Where("id "+op+" ?", p.after.ID)
+		case p.after.Value == nil:
+			query = query.Where(sort.column+" IS NULL AND id "+op+" ?", p.after.ID)
+		default:
+			cond := fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?)", sort.column, op)
+			if sort.nullable {
+				cond += " OR " + sort.column + " IS NULL"
+			}
+			query = query.Where("("+cond+")", p.after.Value, p.after.Value, p.after.ID)
+		}
+	}
+
+	if sort.column != "id" {
+		order := sort.column + " " + dir
+		if sort.nullable {
+			order += " NULLS LAST"
+		}
+		query = query.Order(order)
+	}
+	query = query.Order("id " + dir)
+
+	// fetch one more todo to find out if there is a next page
+	var todos []Todo
+	if err := query.Limit(p.limit + 1).Find(&todos).Error; err != nil {
+		return nil, "", err
+	}
+	if len(todos) <= p.limit {
+		return todos, "", nil
+	}
+
+	todos = todos[:p.limit]
+	next, err := encodeCursor(todos[len(todos)-1], p.sort)
+	return todos, next, err
+}
+
+// findComputed sorts every todo matched by query by its urgency, which
+// changes over time so pages can shift between requests
+func (p TodoPage) findComputed(query *gorm.DB) ([]Todo, string, error) {
+	var todos []Todo
+	if err := query.Find(&todos).Error; err != nil {
+		return nil, "", err
+	}
+	setUrgency(todos)
+
+	before := func(a, b Todo) bool {
+		if a.Urgency == b.Urgency {
+			return p.desc && a.ID > b.ID || !p.desc && a.ID < b.ID
+		}
+		return p.desc && a.Urgency > b.Urgency || !p.desc && a.Urgency < b.Urgency
+	}
+	sort.SliceStable(todos, func(i, j int) bool { return before(todos[i], todos[j]) })
+
+	if p.after != nil {
+		urgency, _ := p.after.Value.(float64)
+		last := Todo{ID: p.after.ID, Urgency: urgency}
+		start := sort.Search(len(todos), func(i int) bool { return before(last, todos[i]) })
+		todos = todos[start:]
+	}
+	if len(todos) <= p.limit {
+		return todos, "", nil
+	}
+
+	todos = todos[:p.limit]
+	next, err := encodeCursor(todos[len(todos)-1], p.sort)
+	return todos, next, err
+}
+
+func encodeCursor(todo Todo, sort string) (string, error) {
+	c := cursor{ID: todo.ID}
+	switch sort {
+	case "due":
+		if todo.Due != nil {
+			c.Value = todo.Due.Format(time.RFC3339Nano)
+		}
+	case "text":
+		c.Value = todo.Text
+	case "priority":
+		c.Value = todo.Priority
+	case "urgency":
+		c.Value = todo.Urgency
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(value, sort string) (*cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(decoded, &c); err != nil {
+		return nil, err
+	}
+
+	// turn the value back into the type of the sorted column
+	numeric := sort == "priority" || sort == "urgency"
+	switch value := c.Value.(type) {
+	case nil:
+	case string:
+		if numeric {
+			return nil, errors.New(ErrInvalidPage)
+		}
+		if sort == "due" {
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, err
+			}
+			c.Value = t
+		}
+	case float64:
+		if !numeric {
+			return nil, errors.New(ErrInvalidPage)
+		}
+		if sort == "priority" {
+			c.Value = int(value)
+		}
+	default:
+		return nil, errors.New(ErrInvalidPage)
+	}
+
+	return &c, nil
+}
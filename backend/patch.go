@@ -0,0 +1,412 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content types of PATCH /todos/{id}, a JSON Merge Patch (RFC 7396) or a
+// JSON Patch (RFC 6902)
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// patchType is the type of a field in the patch schema
+type patchType struct {
+	name  string
+	check func(value interface{}) bool
+}
+
+var (
+	patchString = patchType{"a string", func(value interface{}) bool {
+		_, ok := value.(string)
+		return ok
+	}}
+	patchBool = patchType{"a boolean", func(value interface{}) bool {
+		_, ok := value.(bool)
+		return ok
+	}}
+	patchTime = patchType{"an RFC 3339 time", func(value interface{}) bool {
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339, str)
+		return err == nil
+	}}
+	patchID = patchType{"an id", func(value interface{}) bool {
+		id, ok := value.(float64)
+		return ok && id == math.Trunc(id) && id > 0
+	}}
+	patchPriority = patchType{"none, low, medium, high or a number from 0 to 3", func(value interface{}) bool {
+		_, err := parsePriority(value)
+		return err == nil
+	}}
+	patchStrings = patchType{"a list of strings", func(value interface{}) bool {
+		values, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, v := range values {
+			if _, ok := v.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}}
+)
+
+// todoPatchSchema are the fields of a todo a patch can change, nullable
+// fields can be set to null or removed
+var todoPatchSchema = map[string]struct {
+	typ      patchType
+	nullable bool
+}{
+	"text":         {patchString, false},
+	"completed":    {patchBool, false},
+	"completed_at": {patchTime, true},
+	"due":          {patchTime, true},
+	"priority":     {patchPriority, true},
+	"tags":         {patchStrings, true},
+	"project_id":   {patchID, true},
+	"parent_id":    {patchID, true},
+	"repeat":       {patchString, true},
+}
+
+// PatchError is an invalid patch, Path is the JSON pointer of the field or
+// the operation at fault
+type PatchError struct {
+	Path    string
+	Message string
+	// Failed is set when a test operation of a JSON Patch failed
+	Failed bool
+}
+
+func (e *PatchError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// HandlePATCH changes the fields of a todo given in a JSON Merge Patch or a
+// JSON Patch, the patched todo is checked against todoPatchSchema before
+// the changed fields are saved like in HandlePUT
+func HandlePATCH(w http.ResponseWriter, r *http.Request) {
+	session, todo, ok := findTodoForUpdate(w, r)
+	if !ok {
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != MergePatchType && contentType != JSONPatchType {
+		w.Header().Set("Accept-Patch", MergePatchType+", "+JSONPatchType)
+		writeError(w, http.StatusUnsupportedMediaType, CodeInvalidPatch, ErrPatchType)
+		return
+	}
+	reqBody, _ := ioutil.ReadAll(r.Body)
+	var patch interface{}
+	if err := json.Unmarshal(reqBody, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidPatch, ErrPatchReqBody)
+		return
+	}
+
+	var err error
+	original, patched := todoDocument(todo), todoDocument(todo)
+	if contentType == MergePatchType {
+		err = applyMergePatch(patched, patch)
+	} else {
+		err = applyJSONPatch(patched, patch)
+	}
+	if err == nil {
+		err = validatePatchedTodo(patched)
+	}
+	var patchErr *PatchError
+	if errors.As(err, &patchErr) {
+		status, code := http.StatusBadRequest, CodeInvalidPatch
+		if patchErr.Failed {
+			status, code = http.StatusConflict, CodePatchTestFailed
+		}
+		writeAPIError(w, status, &APIError{
+			Code:    code,
+			Message: patchErr.Error(),
+			Details: map[string]string{"path": patchErr.Path},
+		})
+		return
+	}
+
+	changes := patchChanges(original, patched)
+	if len(changes) == 0 {
+		writeTodo(w, todo)
+		return
+	}
+	saveTodoUpdate(w, session, todo, changes)
+}
+
+// todoDocument is the todo as the JSON object patches apply to, with the
+// fields of todoPatchSchema
+func todoDocument(todo Todo) map[string]interface{} {
+	names := make([]string, len(todo.Tags))
+	for i, tag := range todo.Tags {
+		names[i] = tag.Name
+	}
+	encoded, _ := json.Marshal(map[string]interface{}{
+		"text":         todo.Text,
+		"completed":    todo.Completed,
+		"completed_at": todo.CompletedAt,
+		"due":          todo.Due,
+		"priority":     todo.Priority,
+		"tags":         names,
+		"project_id":   todo.ProjectID,
+		"parent_id":    todo.ParentID,
+		"repeat":       todo.Repeat,
+	})
+	var doc map[string]interface{}
+	_ = json.Unmarshal(encoded, &doc)
+	return doc
+}
+
+// validatePatchedTodo checks every field of the patched todo against
+// todoPatchSchema, a removed field counts as null
+func validatePatchedTodo(doc map[string]interface{}) error {
+	for name, value := range doc {
+		field, ok := todoPatchSchema[name]
+		if !ok {
+			return &PatchError{Path: "/" + name, Message: "unknown field"}
+		}
+		if value == nil {
+			continue
+		}
+		if !field.typ.check(value) {
+			return &PatchError{Path: "/" + name, Message: "has to be " + field.typ.name}
+		}
+	}
+	for name, field := range todoPatchSchema {
+		if doc[name] == nil && !field.nullable {
+			return &PatchError{Path: "/" + name, Message: "can't be null or removed"}
+		}
+	}
+	return nil
+}
+
+// patchChanges returns the request body of HandlePUT with the fields which
+// differ between the original and the patched todo
+func patchChanges(original, patched map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for name := range todoPatchSchema {
+		if !reflect.DeepEqual(original[name], patched[name]) {
+			changes[name] = patched[name]
+		}
+	}
+	if tags, ok := changes["tags"]; ok && tags == nil {
+		changes["tags"] = []interface{}{}
+	}
+	// the completion time is only read along with completed
+	if _, ok := changes["completed_at"]; ok {
+		changes["completed"] = patched["completed"]
+	}
+	return changes
+}
+
+// applyMergePatch applies a JSON Merge Patch to the document, null removes
+// a field
+func applyMergePatch(doc map[string]interface{}, patch interface{}) error {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return &PatchError{Message: "a merge patch has to be a JSON object"}
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(doc, name)
+		} else {
+			doc[name] = value
+		}
+	}
+	return nil
+}
+
+// applyJSONPatch applies the operations of a JSON Patch to the document in
+// order. Paths point at a field like /due or at an element of the tags like
+// /tags/0, /tags/- appends to them.
+func applyJSONPatch(doc map[string]interface{}, patch interface{}) error {
+	ops, ok := patch.([]interface{})
+	if !ok {
+		return &PatchError{Message: "a JSON patch has to be a list of operations"}
+	}
+	for i, value := range ops {
+		op, ok := value.(map[string]interface{})
+		if !ok {
+			return &PatchError{Path: "/" + strconv.Itoa(i), Message: "an operation has to be a JSON object"}
+		}
+		if err := applyPatchOperation(doc, op); err != nil {
+			var patchErr *PatchError
+			if errors.As(err, &patchErr) {
+				patchErr.Message = fmt.Sprintf("operation %d: %s", i, patchErr.Message)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPatchOperation(doc map[string]interface{}, op map[string]interface{}) error {
+	name, _ := op["op"].(string)
+	path, ok := op["path"].(string)
+	if !ok {
+		return &PatchError{Message: "path is missing"}
+	}
+	target, err := parsePatchPointer(path)
+	if err != nil {
+		return err
+	}
+	value, hasValue := op["value"]
+	if !hasValue && (name == "add" || name == "replace" || name == "test") {
+		return &PatchError{Path: path, Message: "value is missing"}
+	}
+
+	switch name {
+	case "add":
+		return target.add(doc, value)
+	case "remove":
+		_, err := target.remove(doc)
+		return err
+	case "replace":
+		if _, err := target.remove(doc); err != nil {
+			return err
+		}
+		return target.add(doc, value)
+	case "move", "copy":
+		from, ok := op["from"].(string)
+		if !ok {
+			return &PatchError{Path: path, Message: "from is missing"}
+		}
+		source, err := parsePatchPointer(from)
+		if err != nil {
+			return err
+		}
+		if name == "move" {
+			if source.field == target.field && source.index == "" && target.index != "" {
+				return &PatchError{Path: path, Message: "a field can't be moved into itself"}
+			}
+			value, err = source.remove(doc)
+		} else {
+			value, err = source.get(doc)
+		}
+		if err != nil {
+			return err
+		}
+		return target.add(doc, copyJSON(value))
+	case "test":
+		current, err := target.get(doc)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return &PatchError{Path: path, Message: "the test failed", Failed: true}
+		}
+		return nil
+	}
+
+	return &PatchError{Path: path, Message: fmt.Sprintf("unknown operation %q", name)}
+}
+
+// patchPointer is a JSON pointer into the document of a todo, index is
+// empty when it points at the field itself
+type patchPointer struct {
+	path, field, index string
+}
+
+func parsePatchPointer(path string) (patchPointer, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "" || parts[1] == "" {
+		return patchPointer{}, &PatchError{Path: path, Message: "invalid path"}
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	pointer := patchPointer{path: path, field: unescape.Replace(parts[1])}
+	if len(parts) == 3 {
+		pointer.index = parts[2]
+	}
+	return pointer, nil
+}
+
+// list returns the list of the field and the position of the index in it,
+// the end of the list counts when end is set
+func (p patchPointer) list(doc map[string]interface{}, end bool) ([]interface{}, int, error) {
+	list, ok := doc[p.field].([]interface{})
+	if !ok {
+		return nil, 0, &PatchError{Path: p.path, Message: "not a list"}
+	}
+	if p.index == "-" && end {
+		return list, len(list), nil
+	}
+	i, err := strconv.Atoi(p.index)
+	limit := len(list) - 1
+	if end {
+		limit = len(list)
+	}
+	if err != nil || i < 0 || i > limit || (p.index != "0" && strings.HasPrefix(p.index, "0")) {
+		return nil, 0, &PatchError{Path: p.path, Message: "index out of range"}
+	}
+	return list, i, nil
+}
+
+func (p patchPointer) get(doc map[string]interface{}) (interface{}, error) {
+	if p.index == "" {
+		value, ok := doc[p.field]
+		if !ok {
+			return nil, &PatchError{Path: p.path, Message: "the field doesn't exist"}
+		}
+		return value, nil
+	}
+	list, i, err := p.list(doc, false)
+	if err != nil {
+		return nil, err
+	}
+	return list[i], nil
+}
+
+func (p patchPointer) add(doc map[string]interface{}, value interface{}) error {
+	if p.index == "" {
+		doc[p.field] = value
+		return nil
+	}
+	list, i, err := p.list(doc, true)
+	if err != nil {
+		return err
+	}
+	added := append(append(append([]interface{}{}, list[:i]...), value), list[i:]...)
+	doc[p.field] = added
+	return nil
+}
+
+func (p patchPointer) remove(doc map[string]interface{}) (interface{}, error) {
+	value, err := p.get(doc)
+	if err != nil {
+		return nil, err
+	}
+	if p.index == "" {
+		delete(doc, p.field)
+		return value, nil
+	}
+	list, i, _ := p.list(doc, false)
+	doc[p.field] = append(append([]interface{}{}, list[:i]...), list[i+1:]...)
+	return value, nil
+}
+
+// copyJSON copies a decoded JSON value so lists are not shared
+func copyJSON(value interface{}) interface{} {
+	encoded, _ := json.Marshal(value)
+	var copied interface{}
+	_ = json.Unmarshal(encoded, &copied)
+	return copied
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestPatchTodo(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	var todo Todo
+	res := httptest.NewRecorder()
+	TodoWithoutID(res, NewAuthRequest("POST", "http://localhost:8080/todos",
+		bytes.NewReader([]byte(`{"text": "plan the party", "due": "2026-12-01T18:00:00Z", "tags": ["home"]}`))))
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := NewAuthRequest("PATCH", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", contentType)
+		res := httptest.NewRecorder()
+		TodoWithID(res, req)
+		return res
+	}
+	decode := func(res *httptest.ResponseRecorder) Todo {
+		var patched Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &patched))
+		return patched
+	}
+
+	t.Run("merge patch", func(t *testing.T) {
+		res := patch(MergePatchType, `{"priority": "high", "due": null}`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		patched := decode(res)
+		if patched.Priority != PriorityHigh || patched.Due != nil || patched.Text != "plan the party" || len(patched.Tags) != 1 {
+			t.Errorf("expected only the priority and due date to change, got %#v", patched)
+		}
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		res := patch(JSONPatchType, `[
+			{"op": "test", "path": "/tags/0", "value": "home"},
+			{"op": "add", "path": "/tags/-", "value": "fun"},
+			{"op": "replace", "path": "/text", "value": "plan the birthday party"},
+			{"op": "copy", "from": "/text", "path": "/repeat"},
+			{"op": "remove", "path": "/repeat"}
+		]`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		patched := decode(res)
+		if patched.Text != "plan the birthday party" || len(patched.Tags) != 2 || patched.Repeat != nil {
+			t.Errorf("expected the text and a second tag, got %#v", patched)
+		}
+	})
+
+	t.Run("a failed test leaves the todo alone", func(t *testing.T) {
+		res := patch(JSONPatchType, `[{"op": "replace", "path": "/text", "value": "x"}, {"op": "test", "path": "/priority", "value": 0}]`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusConflict)
+		assertAPIError(t, res, CodePatchTestFailed)
+		found, err := store.GetTodo(uid, strconv.Itoa(todo.ID))
+		assertRandomErr(t, err)
+		if found.Text != "plan the birthday party" {
+			t.Errorf("expected the todo to be unchanged, got %#v", found)
+		}
+	})
+
+	t.Run("patches are checked against the schema", func(t *testing.T) {
+		invalid := map[string]string{
+			`{"text": null}`:                        "/text",
+			`{"text": 3}`:                           "/text",
+			`{"due": "tomorrow"}`:                   "/due",
+			`{"tags": "home"}`:                      "/tags",
+			`{"project_id": 1.5}`:                   "/project_id",
+			`{"owner": "someone else"}`:             "/owner",
+			`[{"op": "remove", "path": "/text"}]`:   "/text",
+			`[{"op": "add", "path": "/tags/9"}]`:    "/tags/9",
+			`[{"op": "jump", "path": "/text"}]`:     "/text",
+			`[{"op": "add", "path": "/a/b/c"}]`:     "/a/b/c",
+			`[{"op": "remove", "path": "/tags/5"}]`: "/tags/5",
+		}
+		for body, path := range invalid {
+			contentType := MergePatchType
+			if body[0] == '[' {
+				contentType = JSONPatchType
+			}
+			res := patch(contentType, body)
+			assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+			var errRes struct {
+				Error struct {
+					Code    string            `json:"code"`
+					Details map[string]string `json:"details"`
+				} `json:"error"`
+			}
+			assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &errRes))
+			if errRes.Error.Code != CodeInvalidPatch || errRes.Error.Details["path"] != path {
+				t.Errorf("expected %s to be rejected at %s, got %s", body, path, res.Body.String())
+			}
+		}
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		res := patch("application/json", `{"text": "a"}`)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusUnsupportedMediaType)
+		if res.Header().Get("Accept-Patch") == "" {
+			t.Errorf("expected the supported patch types to be listed")
+		}
+	})
+
+	t.Run("authentication comes before the content type", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID), bytes.NewReader([]byte(`{"text": "a"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		res := SendRequest(req, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusUnauthorized)
+		assertAPIError(t, res, CodeUnauthorized)
+	})
+}
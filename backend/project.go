@@ -0,0 +1,225 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Project struct {
+	ID     int    `gorm:"primaryKey" json:"id"`
+	Name   string `json:"name"`
+	UserID int    `gorm:"column:uid" json:"uid"`
+}
+
+func ProjectWithoutID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		HandleGETProjects(w, r)
+	case "POST":
+		HandlePOSTProject(w, r)
+	}
+}
+
+func ProjectWithID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		HandleGETProject(w, r)
+	case "PUT":
+		HandlePUTProject(w, r)
+	case "DELETE":
+		HandleDeleteProject(w, r)
+	}
+}
+
+func HandleGETProjects(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	projects, err := store.ListProjects(uid)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(projects)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+func HandlePOSTProject(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	name, ok := decodeProjectName(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, CodeInvalidProject, ErrProjectReqBody)
+		return
+	}
+	project := Project{Name: name, UserID: uid}
+	err = store.CreateProject(&project)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(project)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+func HandleGETProject(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	project, err := store.GetProject(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if project.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeProjectNotFound, ErrInvalidID)
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(project)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+func HandlePUTProject(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeProjectName(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, CodeInvalidProject, ErrProjectReqBody)
+		return
+	}
+
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+	project, err := store.GetProject(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if project.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeProjectNotFound, ErrInvalidID)
+		return
+	}
+
+	project.Name = name
+	err = store.SaveProject(&project)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(project)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// HandleDeleteProject deletes the project along with its todos when called
+// with todos=delete, otherwise the todos are moved to the project given by
+// move_to, or out of any project if move_to is missing
+func HandleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+	project, err := store.GetProject(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if project.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeProjectNotFound, ErrInvalidID)
+		return
+	}
+
+	params := r.URL.Query()
+	deleteTodos := params.Get("todos") == "delete"
+	var moveTo *int
+	if value := params.Get("move_to"); value != "" && !deleteTodos {
+		target, err := store.GetProject(uid, value)
+		if !assertServerError(err, w) {
+			return
+		}
+		if target.ID == 0 || target.ID == project.ID {
+			writeError(w, http.StatusBadRequest, CodeInvalidProjectID, ErrInvalidProject)
+			return
+		}
+		moveTo = &target.ID
+	}
+
+	err = store.DeleteProject(project, deleteTodos, moveTo)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("Successfully deleted project " + strconv.Itoa(project.ID)))
+}
+
+func HandleGETProjectTodos(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+	project, err := store.GetProject(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if project.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeProjectNotFound, ErrInvalidID)
+		return
+	}
+
+	todos, err := store.ListProjectTodos(uid, project.ID)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	setUrgency(todos)
+	encodedResBody, _ := json.Marshal(todos)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// parseProjectID reads a project id of the user from a decoded request body,
+// null results in a nil id which takes the todo out of its project
+func parseProjectID(uid int, value interface{}) (*int, error) {
+	if value == nil {
+		return nil, nil
+	}
+	id, ok := value.(float64)
+	if !ok {
+		return nil, errors.New(ErrInvalidProject)
+	}
+	project, err := store.GetProject(uid, strconv.Itoa(int(id)))
+	if err != nil {
+		return nil, err
+	}
+	if project.ID == 0 {
+		return nil, errors.New(ErrInvalidProject)
+	}
+
+	return &project.ID, nil
+}
+
+func decodeProjectName(r *http.Request) (string, bool) {
+	reqBody, _ := ioutil.ReadAll(r.Body)
+	var decodedReqBody struct {
+		Name string
+	}
+	if err := json.Unmarshal(reqBody, &decodedReqBody); err != nil {
+		return "", false
+	}
+	name := strings.TrimSpace(decodedReqBody.Name)
+
+	return name, name != ""
+}
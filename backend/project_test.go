@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestProjects(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	infra := createProject(t, "infra")
+	home := createProject(t, "home")
+	createProjectTodo(t, "deploy", infra.ID)
+	createProjectTodo(t, "upgrade db", infra.ID)
+	createProjectTodo(t, "fix bike", home.ID)
+
+	t.Run("list projects", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/projects", nil)
+		res := httptest.NewRecorder()
+		ProjectWithoutID(res, req)
+
+		var projects []Project
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &projects))
+		if len(projects) != 2 {
+			t.Errorf("expected 2 projects, got %#v", projects)
+		}
+	})
+
+	t.Run("get the todos of a project", func(t *testing.T) {
+		todos := getProjectTodos(t, infra.ID)
+		if len(todos) != 2 {
+			t.Errorf("expected 2 todos in the project, got %#v", todos)
+		}
+	})
+
+	t.Run("rename a project", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]string{"name": "infrastructure"})
+		req := NewAuthRequest("PUT", projectURL(infra.ID), bytes.NewReader(reqBody))
+		res := httptest.NewRecorder()
+		ProjectWithID(res, req)
+
+		project := unmarshalAndAssert(t, res)
+		if project["name"] != "infrastructure" {
+			t.Errorf("project was not renamed, got %#v", project)
+		}
+	})
+
+	t.Run("delete a project and move its todos", func(t *testing.T) {
+		req := NewAuthRequest("DELETE", projectURL(home.ID)+"?move_to="+strconv.Itoa(infra.ID), nil)
+		res := httptest.NewRecorder()
+		ProjectWithID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		if todos := getProjectTodos(t, infra.ID); len(todos) != 3 {
+			t.Errorf("expected the todos to be moved, got %#v", todos)
+		}
+	})
+
+	t.Run("delete a project along with its todos", func(t *testing.T) {
+		req := NewAuthRequest("DELETE", projectURL(infra.ID)+"?todos=delete", nil)
+		res := httptest.NewRecorder()
+		ProjectWithID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		_, count, _, err := store.ListTodos(uid, TodoFilter{}, TodoPage{limit: 1, sort: "created"})
+		assertRandomErr(t, err)
+		if count != 0 {
+			t.Errorf("expected the todos to be deleted, found %d", count)
+		}
+	})
+
+	t.Run("todos can't be added to another user's project", func(t *testing.T) {
+		todo := addRandomUserAndTodo()
+		other := Project{Name: "private", UserID: int(todo["uid"].(float64))}
+		assertRandomErr(t, store.CreateProject(&other))
+
+		reqBody, _ := json.Marshal(map[string]interface{}{"text": "sneaky", "project_id": other.ID})
+		req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader(reqBody))
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+	})
+}
+
+func projectURL(id int) string {
+	return "http://localhost:8080/projects/" + strconv.Itoa(id)
+}
+
+func createProject(t *testing.T, name string) Project {
+	t.Helper()
+	reqBody, _ := json.Marshal(map[string]string{"name": name})
+	req := NewAuthRequest("POST", "http://localhost:8080/projects", bytes.NewReader(reqBody))
+	res := httptest.NewRecorder()
+	ProjectWithoutID(res, req)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+	var project Project
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &project))
+	return project
+}
+
+func createProjectTodo(t *testing.T, text string, projectID int) {
+	t.Helper()
+	reqBody, _ := json.Marshal(map[string]interface{}{"text": text, "project_id": projectID})
+	req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader(reqBody))
+	res := httptest.NewRecorder()
+	TodoWithoutID(res, req)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+}
+
+func getProjectTodos(t *testing.T, id int) []Todo {
+	t.Helper()
+	req := NewAuthRequest("GET", projectURL(id)+"/todos", nil)
+	res := httptest.NewRecorder()
+	HandleGETProjectTodos(res, req)
+
+	var todos []Todo
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+	return todos
+}
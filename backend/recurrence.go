@@ -0,0 +1,292 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is the repetition of a todo, written as a subset of the
+// RFC 5545 RRULE like
+//
+//	FREQ=WEEKLY;INTERVAL=2;BYDAY=MO
+//	FREQ=MONTHLY;BYMONTHDAY=-1
+//	FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION
+//
+// The supported parts are FREQ, INTERVAL, BYDAY without ordinals,
+// BYMONTHDAY and UNTIL. X-FROM=COMPLETION counts the interval from the
+// completion of the previous todo instead of its due date.
+type Recurrence struct {
+	Freq           string
+	Interval       int
+	ByDay          []time.Weekday
+	ByMonthDay     []int
+	Until          *time.Time
+	FromCompletion bool
+}
+
+var recurrenceFreqs = []string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+
+var recurrenceDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// recurrenceSearch bounds the steps taken to find the next occurrence,
+// rules like every 7 days on a day the series never lands on have none
+const recurrenceSearch = 1000
+
+// ParseRecurrence reads an RRULE, the RRULE: prefix is optional
+func ParseRecurrence(spec string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	spec = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(spec)), "RRULE:")
+	if spec == "" {
+		return r, errors.New("empty rule")
+	}
+
+	for _, part := range strings.Split(spec, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return r, fmt.Errorf("%q is not a KEY=VALUE part", part)
+		}
+		switch key {
+		case "FREQ":
+			if !contains(recurrenceFreqs, value) {
+				return r, fmt.Errorf("FREQ %s is not one of %s", value, strings.Join(recurrenceFreqs, ", "))
+			}
+			r.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return r, fmt.Errorf("INTERVAL %s is not a positive number", value)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday := indexOf(recurrenceDays, day)
+				if weekday < 0 {
+					return r, fmt.Errorf("BYDAY %s is not one of %s", day, strings.Join(recurrenceDays, ","))
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(weekday))
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return r, fmt.Errorf("BYMONTHDAY %s is not a day from 1 to 31 or -31 to -1", day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, monthDay)
+			}
+		case "UNTIL":
+			until, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				until, err = time.Parse("20060102", value)
+			}
+			if err != nil {
+				return r, fmt.Errorf("UNTIL %s is not a date like 20261231 or 20261231T170000Z", value)
+			}
+			r.Until = &until
+		case "X-FROM":
+			if value != "COMPLETION" {
+				return r, fmt.Errorf("X-FROM %s is not COMPLETION", value)
+			}
+			r.FromCompletion = true
+		default:
+			return r, fmt.Errorf("%s is not supported", key)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return r, errors.New("FREQ is missing")
+	case len(r.ByDay) > 0 && r.Freq != "DAILY" && r.Freq != "WEEKLY":
+		return r, errors.New("BYDAY needs FREQ=DAILY or FREQ=WEEKLY")
+	case len(r.ByMonthDay) > 0 && r.Freq != "MONTHLY":
+		return r, errors.New("BYMONTHDAY needs FREQ=MONTHLY")
+	case r.FromCompletion && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0):
+		return r, errors.New("X-FROM=COMPLETION only repeats by INTERVAL")
+	}
+	return r, nil
+}
+
+// String writes the rule in the form ParseRecurrence reads
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = recurrenceDays[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.FromCompletion {
+		parts = append(parts, "X-FROM=COMPLETION")
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after t at the same time of day,
+// false means the series ended
+func (r Recurrence) Next(t time.Time) (time.Time, bool) {
+	next, ok := r.next(t)
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r Recurrence) next(t time.Time) (time.Time, bool) {
+	switch r.Freq {
+	case "DAILY":
+		for step := 1; step <= recurrenceSearch; step++ {
+			next := t.AddDate(0, 0, step*r.Interval)
+			if r.onDay(next) {
+				return next, true
+			}
+		}
+	case "WEEKLY":
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*r.Interval), true
+		}
+		// weeks start on monday, only every interval-th week counts
+		week := startOfWeek(t)
+		for day := 1; day <= 7*r.Interval; day++ {
+			next := t.AddDate(0, 0, day)
+			weeks := int(startOfWeek(next).Sub(week).Hours()+12) / (7 * 24)
+			if weeks%r.Interval == 0 && r.onDay(next) {
+				return next, true
+			}
+		}
+	case "MONTHLY":
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{t.Day()}
+		}
+		for step := 0; step <= recurrenceSearch; step++ {
+			first := time.Date(t.Year(), t.Month()+time.Month(step*r.Interval), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+			last := first.AddDate(0, 1, -1).Day()
+			// months without the day are skipped, like the 31st in april
+			var candidates []time.Time
+			for _, day := range days {
+				if day < 0 {
+					day = last + day + 1
+				}
+				if day >= 1 && day <= last {
+					candidates = append(candidates, first.AddDate(0, 0, day-1))
+				}
+			}
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+			for _, next := range candidates {
+				if next.After(t) {
+					return next, true
+				}
+			}
+		}
+	case "YEARLY":
+		return t.AddDate(r.Interval, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// onDay reports whether t falls on one of the BYDAY days, any day without them
+func (r Recurrence) onDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextOccurrence builds the todo repeating the completed todo, nil means
+// the series ended. Occurrences are due after the due date of the todo,
+// skipping the ones which passed before it was completed, or after the
+// completion when the rule says so or the todo had no due date.
+func nextOccurrence(todo Todo) (*Todo, error) {
+	if todo.Repeat == nil || todo.CompletedAt == nil {
+		return nil, nil
+	}
+	rule, err := ParseRecurrence(*todo.Repeat)
+	if err != nil {
+		return nil, err
+	}
+
+	completedAt := *todo.CompletedAt
+	next, ok := rule.Next(completedAt)
+	if todo.Due != nil && rule.FromCompletion {
+		// keep the time of day of the due date
+		due := *todo.Due
+		day := completedAt.In(due.Location())
+		anchor := time.Date(day.Year(), day.Month(), day.Day(), due.Hour(), due.Minute(), due.Second(), 0, due.Location())
+		next, ok = rule.Next(anchor)
+	} else if todo.Due != nil {
+		next, ok = rule.Next(*todo.Due)
+		for i := 0; ok && next.Before(completedAt) && i < recurrenceSearch; i++ {
+			next, ok = rule.Next(next)
+		}
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &Todo{
+		Text:      todo.Text,
+		UserID:    todo.UserID,
+		Due:       &next,
+		Tags:      todo.Tags,
+		ProjectID: todo.ProjectID,
+		Priority:  todo.Priority,
+		Repeat:    todo.Repeat,
+		SeriesID:  todo.SeriesID,
+		ParentID:  todo.ParentID,
+	}, nil
+}
+
+// parseRepeat reads the rule of a decoded request body and normalizes it,
+// null results in a nil rule which stops the repetition
+func parseRepeat(value interface{}) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	spec, ok := value.(string)
+	if !ok {
+		return nil, errors.New(ErrInvalidRepeat)
+	}
+	rule, err := ParseRecurrence(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidRepeat, err)
+	}
+
+	normalized := rule.String()
+	return &normalized, nil
+}
@@ -0,0 +1,171 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	t.Run("valid rules are normalized", func(t *testing.T) {
+		valid := map[string]string{
+			"freq=daily":                                "FREQ=DAILY",
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR":    "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO":           "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+			"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20271231": "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20271231T000000Z",
+			"FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION":   "FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION",
+			"FREQ=YEARLY;INTERVAL=1":                    "FREQ=YEARLY",
+		}
+		for spec, want := range valid {
+			rule, err := ParseRecurrence(spec)
+			if err != nil {
+				t.Errorf("didn't expect an error for %#v, got %v", spec, err)
+				continue
+			}
+			if rule.String() != want {
+				t.Errorf("expected %#v to be written as %#v, got %#v", spec, want, rule.String())
+			}
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		invalid := []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=HOURLY",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=MONTHLY;BYMONTHDAY=32",
+			"FREQ=MONTHLY;BYDAY=MO",
+			"FREQ=WEEKLY;BYMONTHDAY=1",
+			"FREQ=WEEKLY;BYDAY=MO;X-FROM=COMPLETION",
+			"FREQ=DAILY;COUNT=3",
+		}
+		for _, spec := range invalid {
+			if _, err := ParseRecurrence(spec); err == nil {
+				t.Errorf("expected an error for %#v", spec)
+			}
+		}
+	})
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(value string) time.Time {
+		d, err := time.Parse("2006-01-02 15:04", value)
+		assertTestError(err)
+		return d
+	}
+	cases := []struct {
+		spec, from, want string
+	}{
+		{"FREQ=DAILY", "2026-10-16 09:00", "2026-10-17 09:00"},
+		// friday to monday
+		{"FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "2026-10-16 09:00", "2026-10-19 09:00"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-10-19 09:00", "2026-11-02 09:00"},
+		// from a wednesday the monday of the next week is skipped
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-10-21 09:00", "2026-11-02 09:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-31 18:00", "2026-02-28 18:00"},
+		{"FREQ=MONTHLY", "2026-01-31 18:00", "2026-03-31 18:00"},
+		{"FREQ=YEARLY", "2026-10-16 09:00", "2027-10-16 09:00"},
+		{"FREQ=WEEKLY;UNTIL=20261020", "2026-10-16 09:00", ""},
+	}
+	for _, c := range cases {
+		rule, err := ParseRecurrence(c.spec)
+		assertTestError(err)
+		next, ok := rule.Next(date(c.from))
+		if c.want == "" {
+			if ok {
+				t.Errorf("expected %s to end after %s, got %s", c.spec, c.from, next)
+			}
+			continue
+		}
+		if !ok || !next.Equal(date(c.want)) {
+			t.Errorf("expected %s after %s to be %s, got %s", c.spec, c.from, c.want, next)
+		}
+	}
+}
+
+func TestRepeatTodos(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	getSeries := func(id int) []Todo {
+		res := SendAuthRequest("GET", "http://localhost:8080/series/"+strconv.Itoa(id), "", SeriesWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		var todos []Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		return todos
+	}
+
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	res := SendAuthRequest("POST", "http://localhost:8080/todos",
+		`{"text": "water plants", "due": "`+due.Format(time.RFC3339)+`", "repeat": "freq=weekly", "tags": ["home"]}`, TodoWithoutID)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	var first Todo
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &first))
+	if first.SeriesID == nil || *first.SeriesID != first.ID || first.Repeat == nil || *first.Repeat != "FREQ=WEEKLY" {
+		t.Fatalf("expected the todo to start a weekly series, got %#v", first)
+	}
+
+	t.Run("completing a todo creates the next one", func(t *testing.T) {
+		res := SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(first.ID), `{"completed": true}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		todos := getSeries(first.ID)
+		if len(todos) != 2 {
+			t.Fatalf("expected two todos in the series, got %#v", todos)
+		}
+		done, next := todos[0], todos[1]
+		if !done.Completed || done.Repeat != nil {
+			t.Errorf("expected the completed todo to hand over its rule, got %#v", done)
+		}
+		if next.Completed || next.Due == nil || !next.Due.Equal(due.AddDate(0, 0, 7)) {
+			t.Errorf("expected an open todo due a week later, got %#v", next)
+		}
+		if next.Repeat == nil || len(next.Tags) != 1 || next.Tags[0].Name != "home" {
+			t.Errorf("expected the next todo to keep the rule and tags, got %#v", next)
+		}
+	})
+
+	t.Run("stopping the series", func(t *testing.T) {
+		res := SendAuthRequest("PUT", "http://localhost:8080/series/"+strconv.Itoa(first.ID), `{"repeat": null}`, SeriesWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		todos := getSeries(first.ID)
+		res = SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(todos[1].ID), `{"completed": true}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		if todos := getSeries(first.ID); len(todos) != 2 {
+			t.Errorf("expected no more todos after stopping the series, got %#v", todos)
+		}
+
+		res = SendAuthRequest("PUT", "http://localhost:8080/series/"+strconv.Itoa(first.ID), `{"repeat": "FREQ=DAILY"}`, SeriesWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidSeries)
+	})
+
+	t.Run("a repeating subtask stays below its parent", func(t *testing.T) {
+		parent := CreateTestTodo(t, `{"text": "chores"}`)
+		child := CreateTestTodo(t, `{"text": "take out the bins", "repeat": "FREQ=WEEKLY", "parent_id": `+strconv.Itoa(parent.ID)+`}`)
+		res := SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(child.ID), `{"completed": true}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		todos := getSeries(child.ID)
+		if len(todos) != 2 || todos[1].ParentID == nil || *todos[1].ParentID != parent.ID {
+			t.Errorf("expected the next todo below the parent, got %#v", todos)
+		}
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		res := SendAuthRequest("POST", "http://localhost:8080/todos", `{"text": "a", "repeat": "FREQ=SOMETIMES"}`, TodoWithoutID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidRepeat)
+	})
+
+	t.Run("unknown series", func(t *testing.T) {
+		res := SendAuthRequest("GET", "http://localhost:8080/series/999", "", SeriesWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeSeriesNotFound)
+	})
+}
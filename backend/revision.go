@@ -0,0 +1,188 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Revision records the change of one field of a todo. Revisions are only
+// ever added, the values are written the way the field is sent in a
+// request body so they can be sent back to revert the change.
+type Revision struct {
+	ID     int `gorm:"primaryKey" json:"id"`
+	TodoID int `json:"todo_id"`
+	// UserID and Client name the user who made the change and the client
+	// of their session
+	UserID    int           `gorm:"column:uid" json:"uid"`
+	Client    string        `json:"client"`
+	Field     string        `json:"field"`
+	OldValue  RevisionValue `json:"old_value"`
+	NewValue  RevisionValue `json:"new_value"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// RevisionValue is the JSON of a field value, it is sent as is
+type RevisionValue string
+
+func (v RevisionValue) MarshalJSON() ([]byte, error) {
+	if v == "" {
+		return []byte("null"), nil
+	}
+	return []byte(v), nil
+}
+
+func (v *RevisionValue) UnmarshalJSON(data []byte) error {
+	*v = RevisionValue(data)
+	return nil
+}
+
+// revisionFields are the fields of a todo which are recorded, in the order
+// their revisions are added
+var revisionFields = []struct {
+	name  string
+	value func(todo Todo) interface{}
+}{
+	{"text", func(todo Todo) interface{} { return todo.Text }},
+	{"completed", func(todo Todo) interface{} { return todo.Completed }},
+	{"due", func(todo Todo) interface{} {
+		// the same time read back from the database may be in another zone
+		if todo.Due == nil {
+			return nil
+		}
+		return todo.Due.UTC()
+	}},
+	{"priority", func(todo Todo) interface{} { return todo.Priority }},
+	{"tags", func(todo Todo) interface{} {
+		names := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			names[i] = tag.Name
+		}
+		sort.Strings(names)
+		return names
+	}},
+	{"project_id", func(todo Todo) interface{} { return todo.ProjectID }},
+	{"parent_id", func(todo Todo) interface{} { return todo.ParentID }},
+	{"repeat", func(todo Todo) interface{} { return todo.Repeat }},
+}
+
+// diffTodo returns a revision for every recorded field which differs
+// between the todo before and after a change made in the session
+func diffTodo(before, after Todo, session Session) []Revision {
+	var revisions []Revision
+	for _, field := range revisionFields {
+		oldValue, _ := json.Marshal(field.value(before))
+		newValue, _ := json.Marshal(field.value(after))
+		if string(oldValue) == string(newValue) {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			TodoID:   after.ID,
+			UserID:   session.UserID,
+			Client:   sessionClient(session),
+			Field:    field.name,
+			OldValue: RevisionValue(oldValue),
+			NewValue: RevisionValue(newValue),
+		})
+	}
+	return revisions
+}
+
+// sessionClient names the client of the session, falling back to its
+// user agent when the client didn't give a name at login
+func sessionClient(session Session) string {
+	if session.ClientName != "" {
+		return session.ClientName
+	}
+	return session.UserAgent
+}
+
+// HandleGETHistory sends the revisions of a todo, oldest first
+func HandleGETHistory(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	todo, err := store.GetTodo(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if todo.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return
+	}
+	revisions, err := store.ListRevisions(todo.ID)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(revisions)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// HandleRevert sets the fields of a todo back to the values they had right
+// after the revision given as {"to": id}, the revert is recorded like any
+// other change
+func HandleRevert(w http.ResponseWriter, r *http.Request) {
+	reqBody, _ := ioutil.ReadAll(r.Body)
+	var decodedReqBody map[string]interface{}
+	err := json.Unmarshal(reqBody, &decodedReqBody)
+	to, ok := decodedReqBody["to"].(float64)
+	if !ok || err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRevert, ErrRevertReqBody)
+		return
+	}
+
+	session, todo, ok := findTodoForUpdate(w, r)
+	if !ok {
+		return
+	}
+	revisions, err := store.ListRevisions(todo.ID)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	changes, err := revertChanges(revisions, int(to))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeRevisionNotFound, err.Error())
+		return
+	}
+	if len(changes) == 0 {
+		writeTodo(w, todo)
+		return
+	}
+	saveTodoUpdate(w, session, todo, changes)
+}
+
+// revertChanges builds the request body which sets every field changed
+// after the revision with the id back to its value at that revision
+func revertChanges(revisions []Revision, to int) (map[string]interface{}, error) {
+	found := false
+	changes := map[string]interface{}{}
+	for _, revision := range revisions {
+		if revision.ID == to {
+			found = true
+			continue
+		}
+		if !found {
+			continue
+		}
+		if _, ok := changes[revision.Field]; ok {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(revision.OldValue), &value); err != nil {
+			return nil, err
+		}
+		changes[revision.Field] = value
+	}
+	if !found {
+		return nil, errors.New(ErrInvalidRevision)
+	}
+	return changes, nil
+}
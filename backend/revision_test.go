@@ -0,0 +1,86 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestTodoHistory(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	getHistory := func(id int) []Revision {
+		res := SendAuthRequest("GET", "http://localhost:8080/todos/"+strconv.Itoa(id)+"/history", "", HandleGETHistory)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		var revisions []Revision
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &revisions))
+		return revisions
+	}
+
+	res := SendAuthRequest("POST", "http://localhost:8080/todos", `{"text": "call the bank"}`, TodoWithoutID)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	var todo Todo
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+	url := "http://localhost:8080/todos/" + strconv.Itoa(todo.ID)
+
+	t.Run("every changed field is recorded", func(t *testing.T) {
+		res := SendAuthRequest("PUT", url, `{"text": "call the bank about the loan", "priority": "high", "tags": ["money"]}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		// unchanged fields are left out
+		res = SendAuthRequest("PUT", url, `{"text": "email the bank about the loan", "priority": 3}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		revisions := getHistory(todo.ID)
+		if len(revisions) != 4 {
+			t.Fatalf("expected 4 revisions, got %#v", revisions)
+		}
+		want := []struct{ field, old, new string }{
+			{"text", `"call the bank"`, `"call the bank about the loan"`},
+			{"priority", `0`, `3`},
+			{"tags", `[]`, `["money"]`},
+			{"text", `"call the bank about the loan"`, `"email the bank about the loan"`},
+		}
+		for i, w := range want {
+			revision := revisions[i]
+			if revision.Field != w.field || string(revision.OldValue) != w.old || string(revision.NewValue) != w.new {
+				t.Errorf("expected %s to change from %s to %s, got %#v", w.field, w.old, w.new, revision)
+			}
+			if revision.UserID != uid || revision.CreatedAt.IsZero() {
+				t.Errorf("expected the user and time of the change, got %#v", revision)
+			}
+		}
+	})
+
+	t.Run("revert to a revision", func(t *testing.T) {
+		revisions := getHistory(todo.ID)
+		res := SendAuthRequest("POST", url+"/revert", `{"to": `+strconv.Itoa(revisions[0].ID)+`}`, HandleRevert)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var reverted Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &reverted))
+		if reverted.Text != "call the bank about the loan" || reverted.Priority != PriorityNone || len(reverted.Tags) != 0 {
+			t.Errorf("expected the todo as it was after the first change, got %#v", reverted)
+		}
+		if revisions := getHistory(todo.ID); len(revisions) != 7 {
+			t.Errorf("expected the revert to be recorded, got %#v", revisions)
+		}
+	})
+
+	t.Run("revert to a revision of another todo", func(t *testing.T) {
+		res := SendAuthRequest("POST", url+"/revert", `{"to": 999}`, HandleRevert)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeRevisionNotFound)
+
+		res = SendAuthRequest("POST", url+"/revert", `{}`, HandleRevert)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidRevert)
+	})
+
+	t.Run("history of an unknown todo", func(t *testing.T) {
+		res := SendAuthRequest("GET", "http://localhost:8080/todos/999/history", "", HandleGETHistory)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeTodoNotFound)
+	})
+}
@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchResult is a todo matching a search along with its rank and the
+// matching fragment of its text, matches are wrapped in <b></b>
+type SearchResult struct {
+	Todo
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type searchMatch struct {
+	ID      int
+	Rank    float64
+	Snippet string
+}
+
+func HandleSearch(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	q := r.URL.Query().Get("q")
+	if len(searchWords(q)) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidSearch, ErrSearchQuery)
+		return
+	}
+	limit := defaultPageLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			writeError(w, http.StatusBadRequest, CodeInvalidPage, ErrInvalidPage)
+			return
+		}
+	}
+
+	results, err := store.SearchTodos(uid, q, limit)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	now := time.Now()
+	for i := range results {
+		results[i].Urgency = urgencyWeights.Urgency(results[i].Todo, now)
+	}
+
+	encodedResBody, _ := json.Marshal(results)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// loadSearchResults loads the matched todos along with their tags,
+// keeping the order of the matches
+func (s gormStore) loadSearchResults(matches []searchMatch) ([]SearchResult, error) {
+	ids := make([]int, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+	var todos []Todo
+	if err := s.db.Preload("Tags").Find(&todos, "id IN ?", ids).Error; err != nil {
+		return nil, err
+	}
+	todosByID := map[int]Todo{}
+	for _, todo := range todos {
+		todosByID[todo.ID] = todo
+	}
+
+	results := []SearchResult{}
+	for _, match := range matches {
+		results = append(results, SearchResult{
+			Todo:    todosByID[match.ID],
+			Rank:    match.Rank,
+			Snippet: match.Snippet,
+		})
+	}
+	return results, nil
+}
+
+// searchWords splits the search into lower case words
+func searchWords(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+// searchQuery turns the search words into a tsquery matching todos that
+// contain every word, words are matched as prefixes
+func searchQuery(q string) string {
+	words := searchWords(q)
+	for i := range words {
+		words[i] += ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	CreateTodoReq(map[string]string{"text": "deploy the api to production"})
+	CreateTodoReq(map[string]string{"text": "write the deployment guide"})
+	CreateTodoReq(map[string]string{"text": "water plants"})
+	addRandomUserAndTodo()
+
+	t.Run("ranked prefix matches with snippets", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos/search?q=deploy", nil)
+		res := httptest.NewRecorder()
+		HandleSearch(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var results []SearchResult
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &results))
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %#v", results)
+		}
+		for _, result := range results {
+			if result.UserID != uid || !strings.Contains(result.Snippet, "<b>") {
+				t.Errorf("expected a highlighted todo of the current user, got %#v", result)
+			}
+		}
+	})
+
+	t.Run("snippets escape the text", func(t *testing.T) {
+		CreateTodoReq(map[string]string{"text": "patch <script>alert(1)</script> & co"})
+		res := SendAuthRequest("GET", "http://localhost:8080/todos/search?q=patch", "", HandleSearch)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var results []SearchResult
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &results))
+		want := "<b>patch</b> &lt;script&gt;alert(1)&lt;/script&gt; &amp; co"
+		if len(results) != 1 || results[0].Snippet != want {
+			t.Errorf("expected the snippet %q, got %#v", want, results)
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos/search?q=%20", nil)
+		res := httptest.NewRecorder()
+		HandleSearch(res, req)
+
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidSearch)
+	})
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// SeriesWithID serves a series, the todos created by repeating a todo.
+// A series is named after the id of its first todo.
+func SeriesWithID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		HandleGETSeries(w, r)
+	case "PUT":
+		HandlePUTSeries(w, r)
+	}
+}
+
+// getSeriesTodos loads the todos of the series in the path along with the
+// session of the request, writing the error response when there are none
+func getSeriesTodos(w http.ResponseWriter, r *http.Request) (Session, []Todo, bool) {
+	session, err := getSession(w, r)
+	if err != nil {
+		return session, nil, false
+	}
+	seriesID, err := strconv.Atoi(ExtractID(r))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeSeriesNotFound, ErrInvalidID)
+		return session, nil, false
+	}
+
+	todos, err := store.ListSeriesTodos(session.UserID, seriesID)
+	if !assertServerError(err, w) {
+		return session, nil, false
+	}
+	if len(todos) == 0 {
+		writeError(w, http.StatusNotFound, CodeSeriesNotFound, ErrInvalidID)
+		return session, nil, false
+	}
+
+	return session, todos, true
+}
+
+func HandleGETSeries(w http.ResponseWriter, r *http.Request) {
+	_, todos, ok := getSeriesTodos(w, r)
+	if !ok {
+		return
+	}
+
+	setUrgency(todos)
+	encodedResBody, _ := json.Marshal(todos)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// HandlePUTSeries changes the rule of the open todos of the series, a null
+// repeat stops the series
+func HandlePUTSeries(w http.ResponseWriter, r *http.Request) {
+	reqBody, _ := ioutil.ReadAll(r.Body)
+	var decodedReqBody map[string]interface{}
+	err := json.Unmarshal(reqBody, &decodedReqBody)
+	value, hasRepeat := decodedReqBody["repeat"]
+	if !hasRepeat || err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidSeries, ErrSeriesReqBody)
+		return
+	}
+	repeat, err := parseRepeat(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRepeat, err.Error())
+		return
+	}
+
+	session, todos, ok := getSeriesTodos(w, r)
+	if !ok {
+		return
+	}
+	changed := 0
+	var revisions []Revision
+	for i := range todos {
+		if todos[i].Completed {
+			continue
+		}
+		before := todos[i]
+		todos[i].Repeat = repeat
+		err = store.SaveTodo(&todos[i])
+		if !assertServerError(err, w) {
+			return
+		}
+		revisions = append(revisions, diffTodo(before, todos[i], session)...)
+		changed++
+	}
+	if changed == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidSeries, ErrSeriesNotOpen)
+		return
+	}
+	if !assertServerError(store.CreateRevisions(revisions), w) {
+		return
+	}
+
+	setUrgency(todos)
+	encodedResBody, _ := json.Marshal(todos)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
@@ -0,0 +1,174 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionTouchInterval limits how often the last use of a session is saved
+const sessionTouchInterval = time.Minute
+
+type Session struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	Token      string    `json:"-"`
+	UserID     int       `gorm:"column:uid" json:"uid"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	ClientName string    `json:"client_name"`
+	// Current marks the session of the request in listings
+	Current bool `gorm:"-" json:"current"`
+}
+
+func SessionWithoutID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		HandleGETSessions(w, r)
+	case "DELETE":
+		HandleDeleteSessions(w, r)
+	}
+}
+
+// NewSession generates a random token for the user and stores it along
+// with the client it was created for
+func NewSession(uid int, userAgent, clientName string) (Session, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return Session{}, err
+	}
+
+	session := Session{
+		Token:      hex.EncodeToString(buf),
+		UserID:     uid,
+		LastUsedAt: time.Now(),
+		UserAgent:  userAgent,
+		ClientName: clientName,
+	}
+	if err := store.CreateSession(&session); err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// GetSession looks up the session for the bearer token in the
+// Authorization header, the returned session is empty if none was found
+func GetSession(r *http.Request) Session {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return Session{}
+	}
+	bearer := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if bearer == "" {
+		return Session{}
+	}
+
+	session, _ := store.GetSession(bearer)
+	if session.ID != 0 && time.Since(session.LastUsedAt) > sessionTouchInterval {
+		session.LastUsedAt = time.Now()
+		if err := store.TouchSession(&session); err != nil {
+			goLogger.Error(err)
+		}
+	}
+	return session
+}
+
+// getSession is getUserId for the handlers which need the whole session
+func getSession(w http.ResponseWriter, r *http.Request) (Session, error) {
+	session := GetSession(r)
+	if session.UserID == 0 {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, ErrAuth)
+		return Session{}, errors.New(ErrAuth)
+	}
+
+	return session, nil
+}
+
+func HandleGETSessions(w http.ResponseWriter, r *http.Request) {
+	current, err := getSession(w, r)
+	if err != nil {
+		return
+	}
+
+	sessions, err := store.ListSessions(current.UserID)
+	if !assertServerError(err, w) {
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current.ID
+	}
+
+	encodedResBody, _ := json.Marshal(sessions)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// HandleDeleteSessions revokes every session of the user, or every other
+// session with ?keep_current=true
+func HandleDeleteSessions(w http.ResponseWriter, r *http.Request) {
+	current, err := getSession(w, r)
+	if err != nil {
+		return
+	}
+	keepCurrent := r.URL.Query().Get("keep_current") == "true"
+
+	sessions, err := store.ListSessions(current.UserID)
+	if !assertServerError(err, w) {
+		return
+	}
+	ids := []int{}
+	for _, session := range sessions {
+		if !keepCurrent || session.ID != current.ID {
+			ids = append(ids, session.ID)
+		}
+	}
+	err = store.DeleteSessions(current.UserID, ids)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("Successfully revoked " + strconv.Itoa(len(ids)) + " sessions"))
+}
+
+// HandleDeleteSession revokes a session of the user, the id current stands
+// for the session of the request
+func HandleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	current, err := getSession(w, r)
+	if err != nil {
+		return
+	}
+
+	id := ExtractID(r)
+	if id == "current" {
+		id = strconv.Itoa(current.ID)
+	}
+	sessions, err := store.ListSessions(current.UserID)
+	if !assertServerError(err, w) {
+		return
+	}
+	var session Session
+	for _, s := range sessions {
+		if strconv.Itoa(s.ID) == id {
+			session = s
+		}
+	}
+	if session.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeSessionNotFound, ErrInvalidID)
+		return
+	}
+
+	err = store.DeleteSessions(current.UserID, []int{session.ID})
+	if !assertServerError(err, w) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("Successfully revoked session " + id))
+}
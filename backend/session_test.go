@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	laptop, err := NewSession(uid, "todo-cli", "laptop")
+	assertRandomErr(t, err)
+	phone, err := NewSession(uid, "todo-cli", "phone")
+	assertRandomErr(t, err)
+
+	t.Run("list the sessions of the user without their tokens", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/sessions", nil)
+		res := httptest.NewRecorder()
+		SessionWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		if strings.Contains(res.Body.String(), laptop.Token) {
+			t.Error("expected the tokens to stay secret")
+		}
+		sessions := getSessions(t)
+		if len(sessions) != 3 {
+			t.Fatalf("expected 3 sessions, got %d", len(sessions))
+		}
+		for _, session := range sessions {
+			if session.Current != (session.Token == token) {
+				t.Errorf("expected only the session of the request to be current, got %#v", session)
+			}
+		}
+	})
+
+	t.Run("revoke another session", func(t *testing.T) {
+		res := deleteSession(t, strconv.Itoa(laptop.ID))
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		session, err := store.GetSession(laptop.Token)
+		assertRandomErr(t, err)
+		if session.ID != 0 {
+			t.Error("expected the laptop session to be revoked")
+		}
+	})
+
+	t.Run("sessions of other users can't be revoked", func(t *testing.T) {
+		other := addRandomUserAndTodo()
+		session, err := NewSession(int(other["uid"].(float64)), "", "")
+		assertRandomErr(t, err)
+
+		res := deleteSession(t, strconv.Itoa(session.ID))
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("revoke every other session", func(t *testing.T) {
+		req := NewAuthRequest("DELETE", "http://localhost:8080/sessions?keep_current=true", nil)
+		res := httptest.NewRecorder()
+		SessionWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		session, err := store.GetSession(phone.Token)
+		assertRandomErr(t, err)
+		if session.ID != 0 {
+			t.Error("expected the phone session to be revoked")
+		}
+		if len(getSessions(t)) != 1 {
+			t.Error("expected the current session to be kept")
+		}
+	})
+
+	t.Run("log out", func(t *testing.T) {
+		res := deleteSession(t, "current")
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		req := NewAuthRequest("GET", "http://localhost:8080/sessions", nil)
+		res = httptest.NewRecorder()
+		SessionWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
+
+func getSessions(t *testing.T) []Session {
+	t.Helper()
+	req := NewAuthRequest("GET", "http://localhost:8080/sessions", nil)
+	res := httptest.NewRecorder()
+	SessionWithoutID(res, req)
+
+	var sessions []Session
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &sessions))
+	// the tokens are not sent, look them up to compare
+	stored, err := store.ListSessions(uid)
+	assertRandomErr(t, err)
+	tokens := map[int]string{}
+	for _, session := range stored {
+		tokens[session.ID] = session.Token
+	}
+	for i := range sessions {
+		sessions[i].Token = tokens[sessions[i].ID]
+	}
+
+	return sessions
+}
+
+func deleteSession(t *testing.T, id string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := NewAuthRequest("DELETE", "http://localhost:8080/sessions/"+id, nil)
+	res := httptest.NewRecorder()
+	HandleDeleteSession(res, req)
+
+	return res
+}
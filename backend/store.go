@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log"
+	"os"
+	"time"
+)
+
+// Store persists users, sessions and todos along with their tags and
+// projects. Lookups of a single record return the zero value when the
+// record does not exist, the error is reserved for failing queries.
+type Store interface {
+	// MigrateUp applies the pending migrations in order
+	MigrateUp() error
+	// MigrateDown reverts the last steps applied migrations
+	MigrateDown(steps int) error
+	// MigrationStatus lists every migration of the store and whether it
+	// has been applied
+	MigrationStatus() ([]MigrationStatus, error)
+
+	// CreateUser fails with errUsernameTaken when the username is in use
+	CreateUser(user *User) error
+	GetUser(id int) (User, error)
+	// GetUserByName returns the user with the username, usernames are unique
+	GetUserByName(uname string) (User, error)
+	UpdatePassword(user *User) error
+
+	CreateSession(session *Session) error
+	GetSession(token string) (Session, error)
+	// ListSessions returns the sessions of the user, recently used first
+	ListSessions(uid int) ([]Session, error)
+	TouchSession(session *Session) error
+	DeleteSessions(uid int, ids []int) error
+
+	CreateTodo(todo *Todo) error
+	GetTodo(uid int, id string) (Todo, error)
+	// ListTodos returns a page of the matching todos, the total number of
+	// matching todos and the cursor of the next page
+	ListTodos(uid int, filter TodoFilter, page TodoPage) ([]Todo, int64, string, error)
+	// SaveTodo saves the todo and bumps its version, it fails with
+	// errStaleTodo when the todo was saved since it was read
+	SaveTodo(todo *Todo) error
+	SetTodoTags(todo *Todo, tags []Tag) error
+	// DeleteTodo moves the todo to the trash along with every todo below
+	// it if deleteChildren is set, otherwise its children move up to its
+	// parent. Todos in the trash are left out of every other lookup.
+	DeleteTodo(todo *Todo, deleteChildren bool) error
+	GetDeletedTodo(uid int, id string) (Todo, error)
+	// ListTrash returns the todos in the trash, recently deleted first
+	ListTrash(uid int) ([]Todo, error)
+	// RestoreTodo takes the todo out of the trash along with the todos
+	// below it which were deleted with it
+	RestoreTodo(todo *Todo) error
+	// EmptyTrash deletes the todos in the trash of the user for good
+	EmptyTrash(uid int) (int64, error)
+	// PurgeTrash deletes the todos put in the trash before the time for good
+	PurgeTrash(before time.Time) (int64, error)
+	// ListSubtree returns every todo below the todo with the id
+	ListSubtree(uid, id int) ([]Todo, error)
+	// CompleteOccurrence saves the completed todo of a series and creates
+	// the next todo of the series
+	CompleteOccurrence(todo, next *Todo) error
+	// ListSeriesTodos returns the todos of the series in order
+	ListSeriesTodos(uid, seriesID int) ([]Todo, error)
+	SearchTodos(uid int, q string, limit int) ([]SearchResult, error)
+	// CreateRevisions records the changes of todos, revisions are never
+	// changed afterwards
+	CreateRevisions(revisions []Revision) error
+	// ListRevisions returns the revisions of the todo, oldest first
+	ListRevisions(todoID int) ([]Revision, error)
+	// AddOperation adds the operation to the journal of its user, dropping
+	// the undone operations and keeping the last depth operations
+	AddOperation(op *Operation, depth int) error
+	// PeekOperation returns the operation the next undo, or redo if undo is
+	// false, of the user applies
+	PeekOperation(uid int, undo bool) (Operation, error)
+	// MarkOperation marks the operation as undone or not, it reports false
+	// when the operation was already marked that way
+	MarkOperation(op Operation, undone bool) (bool, error)
+
+	FindOrCreateTags(uid int, names []string) ([]Tag, error)
+	GetTag(uid int, id string) (Tag, error)
+	FindTagByName(uid int, name string) (Tag, error)
+	ListTagCounts(uid int) ([]TagCount, error)
+	SaveTag(tag *Tag) error
+	// MergeTags moves every todo of src over to dst and deletes src
+	MergeTags(src, dst Tag) error
+
+	CreateProject(project *Project) error
+	GetProject(uid int, id string) (Project, error)
+	ListProjects(uid int) ([]Project, error)
+	ListProjectTodos(uid, projectID int) ([]Todo, error)
+	SaveProject(project *Project) error
+	// DeleteProject deletes the project and moves its todos to the trash if
+	// deleteTodos is set, otherwise its todos are moved to the moveTo project
+	DeleteProject(project Project, deleteTodos bool, moveTo *int) error
+}
+
+// TodoFilter narrows down the todos of ListTodos, zero values match all todos
+type TodoFilter struct {
+	Completed    *bool
+	DueBefore    *time.Time
+	DueAfter     *time.Time
+	Overdue      bool
+	Project      string
+	Tags         []string
+	ExcludedTags []string
+	// Expr is a condition built by ParseFilter
+	Expr     string
+	ExprArgs []interface{}
+}
+
+// InitStore opens the store selected by the config
+func InitStore(cfg Config) (Store, error) {
+	level := gormLogLevel(cfg.LogLevel)
+	if cfg.Store == "sqlite" {
+		return NewSQLiteStore(cfg.SQLite.Path, level)
+	}
+	return NewPostgresStore(cfg.Postgres.DSN(), level)
+}
+
+// gormLogLevel maps the log level of the config to the level of the
+// query logger, only the debug level logs every query
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "debug":
+		return logger.Info
+	case "info", "warn":
+		return logger.Warn
+	case "error":
+		return logger.Error
+	}
+	return logger.Silent
+}
+
+func gormConfig(level logger.LogLevel) *gorm.Config {
+	return &gorm.Config{
+		Logger: logger.New(
+			log.New(os.Stdout, "\r\n", log.LstdFlags),
+			logger.Config{
+				LogLevel:                  level, // Log level
+				IgnoreRecordNotFoundError: true,  // Ignore ErrRecordNotFound error for logger
+				Colorful:                  true,  // Disable color
+			},
+		),
+	}
+}
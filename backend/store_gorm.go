@@ -0,0 +1,460 @@
+package backend
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// gormStore implements the parts of Store shared by the SQL databases
+type gormStore struct {
+	db *gorm.DB
+	// dialect selects the migrations of the database
+	dialect string
+}
+
+// first loads the first record matching the conditions into dest,
+// a missing record is not an error
+func (s gormStore) first(query *gorm.DB, dest interface{}, conds ...interface{}) error {
+	err := query.First(dest, conds...).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s gormStore) CreateUser(user *User) error {
+	err := s.db.Create(user).Error
+	if translator, ok := s.db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		err = translator.Translate(err)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errUsernameTaken
+	}
+	return err
+}
+
+func (s gormStore) GetUser(id int) (User, error) {
+	var user User
+	err := s.first(s.db, &user, "id=?", id)
+	return user, err
+}
+
+func (s gormStore) GetUserByName(uname string) (User, error) {
+	var user User
+	err := s.first(s.db, &user, "uname=?", uname)
+	return user, err
+}
+
+func (s gormStore) UpdatePassword(user *User) error {
+	return s.db.Model(user).Update("pass", user.Pass).Error
+}
+
+func (s gormStore) CreateSession(session *Session) error {
+	return s.db.Create(session).Error
+}
+
+func (s gormStore) GetSession(token string) (Session, error) {
+	var session Session
+	err := s.first(s.db, &session, "token=?", token)
+	return session, err
+}
+
+func (s gormStore) ListSessions(uid int) ([]Session, error) {
+	sessions := []Session{}
+	err := s.db.Order("last_used_at desc, id desc").Find(&sessions, "uid=?", uid).Error
+	return sessions, err
+}
+
+func (s gormStore) TouchSession(session *Session) error {
+	return s.db.Model(session).Update("last_used_at", session.LastUsedAt).Error
+}
+
+func (s gormStore) DeleteSessions(uid int, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.db.Where("uid=? and id IN ?", uid, ids).Delete(&Session{}).Error
+}
+
+func (s gormStore) CreateTodo(todo *Todo) error {
+	todo.Version = 1
+	return s.db.Create(todo).Error
+}
+
+func (s gormStore) GetTodo(uid int, id string) (Todo, error) {
+	var todo Todo
+	err := s.first(s.db.Preload("Tags"), &todo, "id=? and uid=?", id, uid)
+	return todo, err
+}
+
+func (s gormStore) ListTodos(uid int, filter TodoFilter, page TodoPage) ([]Todo, int64, string, error) {
+	query := s.filterTodos(s.db.Where("uid=?", uid), filter).Session(&gorm.Session{})
+
+	// count all matching todos, then fetch the requested page
+	var total int64
+	if err := query.Model(&Todo{}).Count(&total).Error; err != nil {
+		return nil, 0, "", err
+	}
+	todos, next, err := page.find(query.Preload("Tags"))
+	return todos, total, next, err
+}
+
+func (s gormStore) filterTodos(query *gorm.DB, filter TodoFilter) *gorm.DB {
+	if filter.Expr != "" {
+		query = query.Where(filter.Expr, filter.ExprArgs...)
+	}
+	if filter.Completed != nil {
+		query = query.Where("completed=?", *filter.Completed)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due<?", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("due>?", *filter.DueAfter)
+	}
+	if filter.Overdue {
+		query = query.Where("due<? and completed=?", time.Now(), false)
+	}
+	if filter.Project != "" {
+		query = query.Where("project_id=?", filter.Project)
+	}
+
+	tagged := "id IN (SELECT todo_tags.todo_id FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE tags.name = ?)"
+	for _, tag := range filter.Tags {
+		query = query.Where(tagged, tag)
+	}
+	for _, tag := range filter.ExcludedTags {
+		query = query.Where("NOT "+tagged, tag)
+	}
+
+	return query
+}
+
+func (s gormStore) SaveTodo(todo *Todo) error {
+	return s.saveTodo(s.db, todo)
+}
+
+// saveTodo updates the todo if its version is unchanged, Save would
+// insert the todo again when the update matches no row
+func (s gormStore) saveTodo(tx *gorm.DB, todo *Todo) error {
+	todo.Version++
+	result := tx.Model(todo).Where("version=?", todo.Version-1).
+		Select("*").Omit(clause.Associations).Updates(todo)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = errStaleTodo
+	}
+	if result.Error != nil {
+		todo.Version--
+	}
+	return result.Error
+}
+
+func (s gormStore) SetTodoTags(todo *Todo, tags []Tag) error {
+	return s.db.Model(todo).Association("Tags").Replace(tags)
+}
+
+func (s gormStore) DeleteTodo(todo *Todo, deleteChildren bool) error {
+	// the todos deleted together share the time, so they can be restored
+	// together
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ids := []int{todo.ID}
+		if deleteChildren {
+			var children []int
+			err := tx.Raw("SELECT id FROM todos WHERE deleted_at IS NULL AND id IN ("+subtreeQuery+")", todo.ID, todo.UserID).
+				Scan(&children).Error
+			if err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+		} else {
+			// children in the trash move up as well
+			err := tx.Unscoped().Model(&Todo{}).Where("parent_id=?", todo.ID).
+				Updates(map[string]interface{}{"parent_id": todo.ParentID, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&Todo{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"deleted_at": now, "version": gorm.Expr("version + 1")})
+		if result.Error == nil && result.RowsAffected != int64(len(ids)) {
+			return gorm.ErrRecordNotFound
+		}
+		if result.Error == nil {
+			todo.Version++
+		}
+		return result.Error
+	})
+}
+
+func (s gormStore) GetDeletedTodo(uid int, id string) (Todo, error) {
+	var todo Todo
+	err := s.first(s.db.Unscoped().Preload("Tags"), &todo, "id=? and uid=? and deleted_at IS NOT NULL", id, uid)
+	return todo, err
+}
+
+func (s gormStore) ListTrash(uid int) ([]Todo, error) {
+	todos := []Todo{}
+	err := s.db.Unscoped().Preload("Tags").Order("deleted_at desc, id").
+		Find(&todos, "uid=? and deleted_at IS NOT NULL", uid).Error
+	return todos, err
+}
+
+func (s gormStore) RestoreTodo(todo *Todo) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ids := []int{todo.ID}
+		var children []int
+		err := tx.Raw("SELECT id FROM todos WHERE deleted_at = ? AND id IN ("+subtreeQuery+")", todo.DeletedAt, todo.ID, todo.UserID).
+			Scan(&children).Error
+		if err != nil {
+			return err
+		}
+		ids = append(ids, children...)
+		err = tx.Unscoped().Model(&Todo{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+		todo.DeletedAt = gorm.DeletedAt{}
+		todo.Version++
+
+		// a todo whose parent is gone becomes a top level todo
+		if todo.ParentID != nil {
+			var parent Todo
+			if err := s.first(tx, &parent, "id=?", *todo.ParentID); err != nil {
+				return err
+			}
+			if parent.ID == 0 {
+				todo.ParentID = nil
+				todo.Version++
+				return tx.Model(todo).Updates(map[string]interface{}{"parent_id": nil, "version": gorm.Expr("version + 1")}).Error
+			}
+		}
+		return nil
+	})
+}
+
+func (s gormStore) EmptyTrash(uid int) (int64, error) {
+	return s.purgeTodos(s.db.Where("uid=?", uid))
+}
+
+func (s gormStore) PurgeTrash(before time.Time) (int64, error) {
+	return s.purgeTodos(s.db.Where("deleted_at<?", before))
+}
+
+// purgeTodos deletes the todos in the trash matched by query for good
+func (s gormStore) purgeTodos(query *gorm.DB) (int64, error) {
+	var ids []int
+	err := query.Unscoped().Model(&Todo{}).Where("deleted_at IS NOT NULL").Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	var purged int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM revisions WHERE todo_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM operations WHERE todo_id IN ? OR next_id IN ?", ids, ids).Error; err != nil {
+			return err
+		}
+		// children restored on their own lose their parent
+		err := tx.Unscoped().Model(&Todo{}).Where("parent_id IN ?", ids).
+			Updates(map[string]interface{}{"parent_id": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&Todo{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+func (s gormStore) ListSubtree(uid, id int) ([]Todo, error) {
+	todos := []Todo{}
+	err := s.db.Preload("Tags").Order("id").Find(&todos, "uid=? and id IN ("+subtreeQuery+")", uid, id, uid).Error
+	return todos, err
+}
+
+func (s gormStore) CompleteOccurrence(todo, next *Todo) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.saveTodo(tx, todo); err != nil {
+			return err
+		}
+		next.Version = 1
+		return tx.Create(next).Error
+	})
+}
+
+func (s gormStore) ListSeriesTodos(uid, seriesID int) ([]Todo, error) {
+	todos := []Todo{}
+	err := s.db.Preload("Tags").Order("id").Find(&todos, "uid=? and series_id=?", uid, seriesID).Error
+	return todos, err
+}
+
+func (s gormStore) CreateRevisions(revisions []Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	return s.db.Create(&revisions).Error
+}
+
+func (s gormStore) ListRevisions(todoID int) ([]Revision, error) {
+	revisions := []Revision{}
+	err := s.db.Order("id").Find(&revisions, "todo_id=?", todoID).Error
+	return revisions, err
+}
+
+func (s gormStore) AddOperation(op *Operation, depth int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// a new operation can't be followed by a redo
+		if err := tx.Where("uid=? and undone=?", op.UserID, true).Delete(&Operation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(op).Error; err != nil {
+			return err
+		}
+		keep := tx.Model(&Operation{}).Select("id").Where("uid=?", op.UserID).Order("id desc").Limit(depth)
+		return tx.Where("uid=? and id NOT IN (?)", op.UserID, keep).Delete(&Operation{}).Error
+	})
+}
+
+func (s gormStore) PeekOperation(uid int, undo bool) (Operation, error) {
+	var op Operation
+	// undone operations follow the others, the first one was undone last
+	query := s.db.Where("uid=? and undone=?", uid, false).Order("id desc")
+	if !undo {
+		query = s.db.Where("uid=? and undone=?", uid, true).Order("id")
+	}
+	err := s.first(query, &op)
+	return op, err
+}
+
+func (s gormStore) MarkOperation(op Operation, undone bool) (bool, error) {
+	result := s.db.Model(&Operation{}).Where("id=? and undone=?", op.ID, !undone).Update("undone", undone)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (s gormStore) FindOrCreateTags(uid int, names []string) ([]Tag, error) {
+	tags := []Tag{}
+	for _, name := range names {
+		var tag Tag
+		err := s.db.Where(Tag{Name: name, UserID: uid}).FirstOrCreate(&tag).Error
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func (s gormStore) GetTag(uid int, id string) (Tag, error) {
+	var tag Tag
+	err := s.first(s.db, &tag, "id=? and uid=?", id, uid)
+	return tag, err
+}
+
+func (s gormStore) FindTagByName(uid int, name string) (Tag, error) {
+	var tag Tag
+	err := s.first(s.db, &tag, "name=? and uid=?", name, uid)
+	return tag, err
+}
+
+func (s gormStore) ListTagCounts(uid int) ([]TagCount, error) {
+	// count the todos of every tag, including the unused ones
+	tags := []TagCount{}
+	err := s.db.Model(&Tag{}).
+		Select("tags.*, count(todos.id) as count").
+		Joins("left join todo_tags on todo_tags.tag_id = tags.id").
+		Joins("left join todos on todos.id = todo_tags.todo_id and todos.deleted_at is null").
+		Where("tags.uid=?", uid).
+		Group("tags.id").
+		Order("tags.name").
+		Scan(&tags).Error
+	return tags, err
+}
+
+func (s gormStore) SaveTag(tag *Tag) error {
+	return s.db.Save(tag).Error
+}
+
+func (s gormStore) MergeTags(src, dst Tag) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// drop the links of todos which already have both tags
+		err := tx.Exec(
+			"DELETE FROM todo_tags WHERE tag_id=? AND todo_id IN (SELECT todo_id FROM todo_tags WHERE tag_id=?)",
+			src.ID, dst.ID,
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("UPDATE todo_tags SET tag_id=? WHERE tag_id=?", dst.ID, src.ID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&src).Error
+	})
+}
+
+func (s gormStore) CreateProject(project *Project) error {
+	return s.db.Create(project).Error
+}
+
+func (s gormStore) GetProject(uid int, id string) (Project, error) {
+	var project Project
+	err := s.first(s.db, &project, "id=? and uid=?", id, uid)
+	return project, err
+}
+
+func (s gormStore) ListProjects(uid int) ([]Project, error) {
+	projects := []Project{}
+	err := s.db.Order("name").Find(&projects, "uid=?", uid).Error
+	return projects, err
+}
+
+func (s gormStore) ListProjectTodos(uid, projectID int) ([]Todo, error) {
+	todos := []Todo{}
+	err := s.db.Preload("Tags").Find(&todos, "uid=? and project_id=?", uid, projectID).Error
+	return todos, err
+}
+
+func (s gormStore) SaveProject(project *Project) error {
+	return s.db.Save(project).Error
+}
+
+func (s gormStore) DeleteProject(project Project, deleteTodos bool, moveTo *int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if deleteTodos {
+			// children in other projects lose their parent
+			err := tx.Exec(
+				"UPDATE todos SET parent_id=NULL, version=version+1 WHERE parent_id IN (SELECT id FROM todos WHERE project_id=?) AND (project_id IS NULL OR project_id<>?)",
+				project.ID, project.ID,
+			).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("project_id=?", project.ID).Delete(&Todo{}).Error; err != nil {
+				return err
+			}
+			moveTo = nil
+		}
+		// the todos in the trash move as well, the deleted todos are
+		// restored without a project
+		err := tx.Unscoped().Model(&Todo{}).Where("project_id=?", project.ID).
+			Updates(map[string]interface{}{"project_id": moveTo, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&project).Error
+	})
+}
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PostgresStore keeps the data in Postgres and searches todos with its
+// native full-text search
+type PostgresStore struct {
+	gormStore
+}
+
+func NewPostgresStore(dsn string, level logger.LogLevel) (*PostgresStore, error) {
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig(level))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{gormStore{db: db, dialect: "postgres"}}, nil
+}
+
+func (s *PostgresStore) SearchTodos(uid int, q string, limit int) ([]SearchResult, error) {
+	// rank and highlight the matches
+	var matches []searchMatch
+	err := s.db.Raw(`SELECT id, ts_rank(search, query) AS rank, ts_headline('english', text, query) AS snippet
+		FROM todos, to_tsquery('english', ?) query
+		WHERE uid = ? AND deleted_at IS NULL AND search @@ query
+		ORDER BY rank DESC, id
+		LIMIT ?`, searchQuery(q), uid, limit).Scan(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loadSearchResults(matches)
+}
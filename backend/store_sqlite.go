@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SQLiteStore keeps the data in a single file, searching todos falls back
+// to matching words with LIKE
+type SQLiteStore struct {
+	gormStore
+}
+
+func NewSQLiteStore(path string, level logger.LogLevel) (*SQLiteStore, error) {
+	// sqlite only checks the foreign keys when they are turned on for
+	// every connection
+	db, err := gorm.Open(sqlite.Open(path+"?_foreign_keys=on"), gormConfig(level))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteStore{gormStore{db: db, dialect: "sqlite"}}, nil
+}
+
+func (s *SQLiteStore) SearchTodos(uid int, q string, limit int) ([]SearchResult, error) {
+	words := searchWords(q)
+	if len(words) == 0 {
+		return []SearchResult{}, nil
+	}
+	query := s.db.Where("uid=?", uid)
+	for _, word := range words {
+		query = query.Where(`LOWER(text) LIKE ? ESCAPE '\'`, "%"+escapeLike(word)+"%")
+	}
+	var todos []Todo
+	if err := query.Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	// rank by the number of occurrences and highlight them
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+	matches := make([]searchMatch, len(todos))
+	for i, todo := range todos {
+		found := re.FindAllStringIndex(todo.Text, -1)
+		matches[i] = searchMatch{
+			ID:      todo.ID,
+			Rank:    float64(len(found)),
+			Snippet: highlight(todo.Text, found),
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return s.loadSearchResults(matches)
+}
+
+// highlight wraps the found parts of the text in <b> tags, the text is
+// HTML-escaped so the tags are the only markup of the snippet
+func highlight(text string, found [][]int) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range found {
+		sb.WriteString(html.EscapeString(text[last:loc[0]]))
+		sb.WriteString("<b>" + html.EscapeString(text[loc[0]:loc[1]]) + "</b>")
+		last = loc[1]
+	}
+	sb.WriteString(html.EscapeString(text[last:]))
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type Tag struct {
+	ID     int    `gorm:"primaryKey" json:"id"`
+	Name   string `json:"name"`
+	UserID int    `gorm:"column:uid" json:"uid"`
+}
+
+// TagCount is a tag along with the number of todos using it
+type TagCount struct {
+	Tag
+	Count int `json:"count"`
+}
+
+func HandleGETTags(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	tags, err := store.ListTagCounts(uid)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(tags)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// HandleRenameTag renames a tag, renaming it to the name of another tag
+// merges the two into the existing one
+func HandleRenameTag(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	reqBody, _ := ioutil.ReadAll(r.Body)
+	var decodedReqBody struct {
+		Name string
+	}
+	err = json.Unmarshal(reqBody, &decodedReqBody)
+	name, nameErr := normalizeTagName(decodedReqBody.Name)
+	if err != nil || nameErr != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidTags, ErrTagReqBody)
+		return
+	}
+
+	tag, err := store.GetTag(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if tag.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeTagNotFound, ErrInvalidID)
+		return
+	}
+
+	existing, err := store.FindTagByName(uid, name)
+	if !assertServerError(err, w) {
+		return
+	}
+	if existing.ID == 0 || existing.ID == tag.ID {
+		tag.Name = name
+		err = store.SaveTag(&tag)
+	} else {
+		err = store.MergeTags(tag, existing)
+		tag = existing
+	}
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(tag)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// parseTagNames reads a list of tag names from a decoded request body
+func parseTagNames(value interface{}) ([]string, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New(ErrTagReqBody)
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			return nil, errors.New(ErrTagReqBody)
+		}
+		name, err := normalizeTagName(str)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// tag names can't be empty or start with "-", which is used to exclude
+// tags when filtering
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.HasPrefix(name, "-") {
+		return "", errors.New(ErrTagReqBody)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	createTaggedTodo(t, "write report", "work")
+	createTaggedTodo(t, "fix bike", "home", "someday")
+	createTaggedTodo(t, "deploy", "work", "someday")
+
+	t.Run("filter todos by tag", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?tag=work&tag=-someday", nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+
+		var todos []map[string]interface{}
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		if len(todos) != 1 || todos[0]["text"] != "write report" {
+			t.Errorf("expected only the todo tagged work but not someday, got %#v", todos)
+		}
+	})
+
+	t.Run("list tags with usage counts", func(t *testing.T) {
+		counts := getTagCounts(t)
+		want := map[string]int{"home": 1, "someday": 2, "work": 2}
+		for name, count := range want {
+			if counts[name].Count != count {
+				t.Errorf("expected tag %s to be used %d times, got %d", name, count, counts[name].Count)
+			}
+		}
+	})
+
+	t.Run("rename a tag", func(t *testing.T) {
+		res := renameTag(t, getTagCounts(t)["home"].ID, "household")
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		counts := getTagCounts(t)
+		if _, ok := counts["home"]; ok || counts["household"].Count != 1 {
+			t.Errorf("tag was not renamed, got %#v", counts)
+		}
+	})
+
+	t.Run("renaming to an existing tag merges them", func(t *testing.T) {
+		res := renameTag(t, getTagCounts(t)["someday"].ID, "work")
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		counts := getTagCounts(t)
+		if _, ok := counts["someday"]; ok || counts["work"].Count != 3 {
+			t.Errorf("tags were not merged, got %#v", counts)
+		}
+	})
+
+	t.Run("one user is not able to rename another user's tag", func(t *testing.T) {
+		todo := addRandomUserAndTodo()
+		other, err := store.FindOrCreateTags(int(todo["uid"].(float64)), []string{"private"})
+		assertRandomErr(t, err)
+
+		res := renameTag(t, other[0].ID, "mine")
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+	})
+}
+
+func createTaggedTodo(t *testing.T, text string, tags ...string) {
+	t.Helper()
+	reqBody, _ := json.Marshal(map[string]interface{}{"text": text, "tags": tags})
+	req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader(reqBody))
+	res := httptest.NewRecorder()
+	TodoWithoutID(res, req)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+}
+
+func getTagCounts(t *testing.T) map[string]TagCount {
+	t.Helper()
+	req := NewAuthRequest("GET", "http://localhost:8080/tags", nil)
+	res := httptest.NewRecorder()
+	HandleGETTags(res, req)
+
+	var tags []TagCount
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &tags))
+	counts := map[string]TagCount{}
+	for _, tag := range tags {
+		counts[tag.Name] = tag
+	}
+
+	return counts
+}
+
+func renameTag(t *testing.T, id int, name string) *httptest.ResponseRecorder {
+	t.Helper()
+	reqBody, _ := json.Marshal(map[string]string{"name": name})
+	req := NewAuthRequest("PUT", "http://localhost:8080/tags/"+strconv.Itoa(id), bytes.NewReader(reqBody))
+	res := httptest.NewRecorder()
+	HandleRenameTag(res, req)
+
+	return res
+}
@@ -2,36 +2,58 @@ package backend
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Todo struct {
-	Text   string `json:"text"`
-	ID     int    `gorm:"primaryKey" json:"id"`
-	UserID int    `gorm:"column:uid" json:"uid"`
+	Text        string     `json:"text"`
+	ID          int        `gorm:"primaryKey" json:"id"`
+	UserID      int        `gorm:"column:uid" json:"uid"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Due         *time.Time `json:"due"`
+	Tags        []Tag      `gorm:"many2many:todo_tags" json:"tags"`
+	ProjectID   *int       `gorm:"column:project_id" json:"project_id"`
+	Priority    int        `json:"priority"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// Repeat is the Recurrence of the todo, it moves on to the next todo
+	// of the series once the todo is completed
+	Repeat   *string `json:"repeat"`
+	SeriesID *int    `gorm:"column:series_id" json:"series_id"`
+	ParentID *int    `gorm:"column:parent_id" json:"parent_id"`
+	// Children and Progress are filled in for the todos of a tree,
+	// Progress is left out without children
+	Children []Todo    `gorm:"-" json:"children,omitempty"`
+	Progress *Progress `gorm:"-" json:"progress,omitempty"`
+	// DeletedAt is set while the todo is in the trash
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+	// Version counts the saves of the todo, it is sent as the ETag
+	Version int `json:"version"`
+	// Urgency is computed from the other fields, see UrgencyWeights
+	Urgency float64 `gorm:"-" json:"urgency"`
 }
 
-func userMiddleware(w http.ResponseWriter, _ *http.Request) {
-	// check if the secret exists
-	data, err := ioutil.ReadFile("/tmp/secret.txt")
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		_, _ = w.Write([]byte(ErrAuth))
+func userMiddleware(w http.ResponseWriter, r *http.Request) {
+	// check if the bearer token belongs to a session
+	session := GetSession(r)
+	if session.UserID == 0 {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, ErrAuth)
 		return
 	}
-	// check if the found data is valid
-	var user User
-	db.First(&user, "id=?", string(data))
+	// check if the session user is valid
+	user, _ := store.GetUser(session.UserID)
 	// if not, send error code and body
 	if user.ID == 0 {
-		w.WriteHeader(http.StatusUnauthorized)
-		_, _ = w.Write([]byte(ErrAuth))
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, ErrAuth)
 	}
 }
 
@@ -50,6 +72,8 @@ func TodoWithID(w http.ResponseWriter, r *http.Request) {
 		HandleGETOne(w, r)
 	case "PUT":
 		HandlePUT(w, r)
+	case "PATCH":
+		HandlePATCH(w, r)
 	case "DELETE":
 		HandleDelete(w, r)
 	}
@@ -57,32 +81,70 @@ func TodoWithID(w http.ResponseWriter, r *http.Request) {
 
 func HandleGETOne(w http.ResponseWriter, r *http.Request) {
 	// get the uid
-	uid, err := getUserId(w)
+	uid, err := getUserId(w, r)
 	if err != nil {
 		return
 	}
 
-	todo := GetTodoByID(uid, r)
+	todo, err := GetTodoByID(uid, r)
+	if !assertServerError(err, w) {
+		return
+	}
 	if todo.ID == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = w.Write([]byte(ErrInvalidID))
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, todoETag(todo), true) {
+		w.Header().Set("ETag", todoETag(todo))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	todo.Urgency = urgencyWeights.Urgency(todo, time.Now())
+	if !assertServerError(setProgress(&todo), w) {
 		return
 	}
 	resBody, _ := json.Marshal(todo)
+	w.Header().Set("ETag", todoETag(todo))
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(resBody)
 }
 
-func HandleGETAll(w http.ResponseWriter, _ *http.Request) {
-	// get the secret user id
-	uid, err := getUserId(w)
+func HandleGETAll(w http.ResponseWriter, r *http.Request) {
+	// get the session user id
+	uid, err := getUserId(w, r)
 	if err != nil {
 		return
 	}
 
 	// use the user id to get data from todo table
-	var todos []Todo
-	db.Find(&todos, "uid=?", uid)
+	filter, err := parseTodoFilter(uid, r)
+	var filterErr *FilterError
+	if errors.As(err, &filterErr) {
+		writeAPIError(w, http.StatusBadRequest, &APIError{
+			Code:    CodeInvalidFilter,
+			Message: filterErr.Message,
+			Details: map[string]int{"position": filterErr.Position},
+		})
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidDue, err.Error())
+		return
+	}
+	page, err := parseTodoPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidPage, err.Error())
+		return
+	}
+
+	todos, total, next, err := store.ListTodos(uid, filter, page)
+	if !assertServerError(err, w) {
+		return
+	}
+	setUrgency(todos)
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
 
 	encodedData, _ := json.Marshal(todos)
 	w.WriteHeader(http.StatusOK)
@@ -90,8 +152,8 @@ func HandleGETAll(w http.ResponseWriter, _ *http.Request) {
 }
 
 func HandlePOST(w http.ResponseWriter, r *http.Request) {
-	// get the secret user id
-	uid, err := getUserId(w)
+	// get the session user id
+	uid, err := getUserId(w, r)
 	if err != nil {
 		return
 	}
@@ -100,15 +162,78 @@ func HandlePOST(w http.ResponseWriter, r *http.Request) {
 	var decodedReqBody map[string]interface{}
 	err = json.Unmarshal(reqBody, &decodedReqBody)
 	// check if the decodedReqBody includes text field
-	if decodedReqBody["text"] == nil || err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = fmt.Fprint(w, ErrTodoReqBody)
+	text, ok := decodedReqBody["text"].(string)
+	if !ok || err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidTodo, ErrTodoReqBody)
 		return
 	}
-	createdTodo := Todo{Text: decodedReqBody["text"].(string), UserID: uid}
-	db.Create(&createdTodo)
+	due, err := parseTime(decodedReqBody["due"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidDue, ErrInvalidDue)
+		return
+	}
+	tags := []Tag{}
+	if decodedReqBody["tags"] != nil {
+		names, err := parseTagNames(decodedReqBody["tags"])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidTags, ErrTagReqBody)
+			return
+		}
+		tags, err = store.FindOrCreateTags(uid, names)
+		if !assertServerError(err, w) {
+			return
+		}
+	}
+	projectID, err := parseProjectID(uid, decodedReqBody["project_id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidProjectID, ErrInvalidProject)
+		return
+	}
+	priority, err := parsePriority(decodedReqBody["priority"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidPriority, ErrInvalidPriority)
+		return
+	}
+	repeat, err := parseRepeat(decodedReqBody["repeat"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRepeat, err.Error())
+		return
+	}
+	parentID, err := parseParentID(uid, 0, decodedReqBody["parent_id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParent, ErrInvalidParent)
+		return
+	}
+	createdTodo := Todo{
+		Text:      text,
+		UserID:    uid,
+		Due:       due,
+		Tags:      tags,
+		ProjectID: projectID,
+		Priority:  priority,
+		Repeat:    repeat,
+		ParentID:  parentID,
+	}
+	err = store.CreateTodo(&createdTodo)
+	if !assertServerError(err, w) {
+		return
+	}
+	// a repeating todo starts a series named after it
+	if repeat != nil {
+		createdTodo.SeriesID = &createdTodo.ID
+		err = store.SaveTodo(&createdTodo)
+		if !assertServerError(err, w) {
+			return
+		}
+	}
+	err = journalCreate(createdTodo)
+	if !assertServerError(err, w) {
+		return
+	}
+	createdTodo.Urgency = urgencyWeights.Urgency(createdTodo, time.Now())
 	encodedResBody, _ := json.Marshal(createdTodo)
 
+	w.Header().Set("ETag", todoETag(createdTodo))
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(encodedResBody)
 }
@@ -116,52 +241,268 @@ func HandlePOST(w http.ResponseWriter, r *http.Request) {
 func HandlePUT(w http.ResponseWriter, r *http.Request) {
 	reqBody, _ := ioutil.ReadAll(r.Body)
 	var decodedReqBody map[string]interface{}
-	err := json.Unmarshal(reqBody, &decodedReqBody)
+	if err := json.Unmarshal(reqBody, &decodedReqBody); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidTodoUpdate, ErrTodoUpdateReqBody)
+		return
+	}
 
-	// check if the decodedReqBody includes text field
-	if decodedReqBody["text"] == nil || err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = fmt.Fprint(w, ErrTodoReqBody)
+	session, todo, ok := findTodoForUpdate(w, r)
+	if !ok {
 		return
 	}
+	saveTodoUpdate(w, session, todo, decodedReqBody)
+}
 
-	// check if todo exists and update
-	uid, err := getUserId(w)
+// findTodoForUpdate looks up the todo of the request and checks it against
+// the If-Match header, the error response is sent when it returns false
+func findTodoForUpdate(w http.ResponseWriter, r *http.Request) (Session, Todo, bool) {
+	session, err := getSession(w, r)
 	if err != nil {
-		return
+		return session, Todo{}, false
+	}
+	todo, err := store.GetTodo(session.UserID, ExtractID(r))
+	if !assertServerError(err, w) {
+		return session, todo, false
 	}
-	todo := GetTodoByID(uid, r)
 	if todo.ID == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = w.Write([]byte(ErrInvalidID))
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return session, todo, false
+	}
+
+	return session, todo, checkIfMatch(w, r, todo)
+}
+
+// saveTodoUpdate changes the fields of the todo which are in the decoded
+// request body, saving it against the version it was read at, and sends
+// the todo
+func saveTodoUpdate(w http.ResponseWriter, session Session, todo Todo, decodedReqBody map[string]interface{}) {
+	before := todo
+	next, err := changeTodo(session, &todo, decodedReqBody)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		writeAPIError(w, http.StatusBadRequest, apiErr)
+		return
+	}
+	if errors.Is(err, errStaleTodo) {
+		writeError(w, http.StatusPreconditionFailed, CodeStaleTodo, ErrStaleTodo)
+		return
+	}
+	if !assertServerError(err, w) {
+		return
+	}
+	err = journalUpdate(session.UserID, before, todo, next)
+	if !assertServerError(err, w) {
 		return
 	}
 
-	todo.Text = decodedReqBody["text"].(string)
-	db.Save(&todo)
+	writeTodo(w, todo)
+}
 
-	// send the response
+// writeTodo sends the todo with its urgency, progress and ETag
+func writeTodo(w http.ResponseWriter, todo Todo) {
+	todo.Urgency = urgencyWeights.Urgency(todo, time.Now())
+	if !assertServerError(setProgress(&todo), w) {
+		return
+	}
 	encodedResBody, _ := json.Marshal(todo)
+	w.Header().Set("ETag", todoETag(todo))
 	_, _ = w.Write(encodedResBody)
 }
 
+// errStaleTodo is returned when a todo was saved since it was read
+var errStaleTodo = errors.New(ErrStaleTodo)
+
+// todoETag is the entity tag of the todo, it names the version of the todo
+func todoETag(todo Todo) string {
+	return `"` + strconv.Itoa(todo.Version) + `"`
+}
+
+// etagMatches reports whether the entity tag is in the list of an If-Match
+// or If-None-Match header, * matches any tag. Weak tags like W/"3" only
+// match when weak is set, as If-None-Match compares them.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch sends 412 Precondition Failed with the current ETag when
+// the If-Match header of a change doesn't match the todo
+func checkIfMatch(w http.ResponseWriter, r *http.Request, todo Todo) bool {
+	match := r.Header.Get("If-Match")
+	if match == "" || etagMatches(match, todoETag(todo), false) {
+		return true
+	}
+	w.Header().Set("ETag", todoETag(todo))
+	writeAPIError(w, http.StatusPreconditionFailed, &APIError{
+		Code:    CodeStaleTodo,
+		Message: ErrStaleTodo,
+		Details: map[string]int{"version": todo.Version},
+	})
+	return false
+}
+
+// changeTodo changes the fields of the todo which are in the decoded
+// request body, saves it and records a revision for every changed field.
+// Invalid fields result in an *APIError. Completing a repeating todo
+// returns the next todo of the series.
+func changeTodo(session Session, todo *Todo, decodedReqBody map[string]interface{}) (*Todo, error) {
+	// check if the decodedReqBody includes at least one valid field
+	text, hasText := decodedReqBody["text"].(string)
+	completed, hasCompleted := decodedReqBody["completed"].(bool)
+	_, hasDue := decodedReqBody["due"]
+	_, hasTags := decodedReqBody["tags"]
+	_, hasProject := decodedReqBody["project_id"]
+	_, hasPriority := decodedReqBody["priority"]
+	_, hasRepeat := decodedReqBody["repeat"]
+	_, hasParent := decodedReqBody["parent_id"]
+	if !hasText && !hasCompleted && !hasDue && !hasTags && !hasProject && !hasPriority && !hasRepeat && !hasParent {
+		return nil, &APIError{Code: CodeInvalidTodoUpdate, Message: ErrTodoUpdateReqBody}
+	}
+	due, err := parseTime(decodedReqBody["due"])
+	if err != nil {
+		return nil, &APIError{Code: CodeInvalidDue, Message: ErrInvalidDue}
+	}
+	priority, err := parsePriority(decodedReqBody["priority"])
+	if err != nil {
+		return nil, &APIError{Code: CodeInvalidPriority, Message: ErrInvalidPriority}
+	}
+	repeat, err := parseRepeat(decodedReqBody["repeat"])
+	if err != nil {
+		return nil, &APIError{Code: CodeInvalidRepeat, Message: err.Error()}
+	}
+	var tagNames []string
+	if hasTags {
+		tagNames, err = parseTagNames(decodedReqBody["tags"])
+		if err != nil {
+			return nil, &APIError{Code: CodeInvalidTags, Message: ErrTagReqBody}
+		}
+	}
+	var completedAt *time.Time
+	if value, ok := decodedReqBody["completed_at"].(string); ok && hasCompleted && completed {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, &APIError{Code: CodeInvalidTodoUpdate, Message: ErrTodoUpdateReqBody}
+		}
+		completedAt = &t
+	}
+
+	uid := session.UserID
+	before := *todo
+	if hasText {
+		todo.Text = text
+	}
+	wasCompleted := todo.Completed
+	if hasCompleted {
+		setCompleted(todo, completed, completedAt)
+	}
+	if hasDue {
+		todo.Due = due
+	}
+	if hasPriority {
+		todo.Priority = priority
+	}
+	if hasRepeat {
+		todo.Repeat = repeat
+		if repeat != nil && todo.SeriesID == nil {
+			todo.SeriesID = &todo.ID
+		}
+	}
+	if hasProject {
+		todo.ProjectID, err = parseProjectID(uid, decodedReqBody["project_id"])
+		if err != nil {
+			return nil, &APIError{Code: CodeInvalidProjectID, Message: ErrInvalidProject}
+		}
+	}
+	if hasParent {
+		todo.ParentID, err = parseParentID(uid, todo.ID, decodedReqBody["parent_id"])
+		if err != nil {
+			return nil, &APIError{Code: CodeInvalidParent, Message: ErrInvalidParent}
+		}
+	}
+	if hasTags {
+		todo.Tags, err = store.FindOrCreateTags(uid, tagNames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// completing a repeating todo hands its rule over to the next todo
+	// of the series
+	var next *Todo
+	if todo.Completed && !wasCompleted {
+		next, err = nextOccurrence(*todo)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			todo.Repeat = nil
+		}
+	}
+	if next != nil {
+		err = store.CompleteOccurrence(todo, next)
+	} else {
+		err = store.SaveTodo(todo)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hasTags {
+		if err := store.SetTodoTags(todo, todo.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	return next, store.CreateRevisions(diffTodo(before, *todo, session))
+}
+
+// HandleDelete deletes the todo along with every todo below it when called
+// with children=delete, otherwise its children move up to its parent
 func HandleDelete(w http.ResponseWriter, r *http.Request) {
-	uid, err := getUserId(w)
+	uid, err := getUserId(w, r)
 	if err != nil {
 		return
 	}
 	// get the id
-	todo := GetTodoByID(uid, r)
+	todo, err := GetTodoByID(uid, r)
+	if !assertServerError(err, w) {
+		return
+	}
 	if todo.ID == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = w.Write([]byte(ErrInvalidID))
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return
+	}
+
+	if !checkIfMatch(w, r, todo) {
 		return
 	}
 
-	tx := db.Delete(&todo)
-	if tx.RowsAffected != 1 {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte(ErrInternal))
+	deleteChildren := r.URL.Query().Get("children") == "delete"
+	var children []int
+	if !deleteChildren {
+		descendants, err := store.ListSubtree(uid, todo.ID)
+		if !assertServerError(err, w) {
+			return
+		}
+		for _, descendant := range descendants {
+			if *descendant.ParentID == todo.ID {
+				children = append(children, descendant.ID)
+			}
+		}
+	}
+	err = store.DeleteTodo(&todo, deleteChildren)
+	if !assertServerError(err, w) {
+		return
+	}
+	err = journalDelete(todo, children, deleteChildren)
+	if !assertServerError(err, w) {
 		return
 	}
 
@@ -169,7 +510,96 @@ func HandleDelete(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("Successfully deleted id " + strconv.Itoa(todo.ID)))
 }
 
-func GetTodoByID(uid int, r *http.Request) Todo {
+// parseTodoFilter reads the filters given in the query string of the
+// request, q takes a filter expression (see ParseFilter)
+func parseTodoFilter(uid int, r *http.Request) (TodoFilter, error) {
+	params := r.URL.Query()
+	var filter TodoFilter
+
+	if value := params.Get("q"); value != "" {
+		cond, args, err := ParseFilter(value, uid)
+		if err != nil {
+			return filter, err
+		}
+		filter.Expr, filter.ExprArgs = cond, args
+	}
+
+	switch params.Get("completed") {
+	case "true":
+		completed := true
+		filter.Completed = &completed
+	case "false":
+		completed := false
+		filter.Completed = &completed
+	}
+
+	if value := params.Get("due_before"); value != "" {
+		dueBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New(ErrInvalidDue)
+		}
+		filter.DueBefore = &dueBefore
+	}
+	if value := params.Get("due_after"); value != "" {
+		dueAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New(ErrInvalidDue)
+		}
+		filter.DueAfter = &dueAfter
+	}
+	filter.Overdue = params.Get("overdue") == "true"
+	filter.Project = params.Get("project")
+
+	// tag=work includes todos tagged work, tag=-work excludes them
+	for _, tag := range params["tag"] {
+		if strings.HasPrefix(tag, "-") {
+			filter.ExcludedTags = append(filter.ExcludedTags, strings.TrimPrefix(tag, "-"))
+		} else {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTime parses an RFC 3339 timestamp from a decoded request body,
+// a missing or null value results in a nil time
+func parseTime(value interface{}) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return nil, errors.New(ErrInvalidDue)
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// setCompleted marks the todo as completed at the given time, or now if
+// at is nil, and clears the completion time when completed is false
+func setCompleted(todo *Todo, completed bool, at *time.Time) {
+	if !completed {
+		todo.Completed = false
+		todo.CompletedAt = nil
+		return
+	}
+	if at == nil {
+		if todo.Completed && todo.CompletedAt != nil {
+			return
+		}
+		now := time.Now()
+		at = &now
+	}
+	todo.Completed = true
+	todo.CompletedAt = at
+}
+
+func GetTodoByID(uid int, r *http.Request) (Todo, error) {
 	var id string
 
 	if mode == "prod" {
@@ -179,37 +609,68 @@ func GetTodoByID(uid int, r *http.Request) Todo {
 		id = string(re.FindSubmatch([]byte(r.URL.Path))[1])
 	}
 
-	var todo Todo
-	db.First(&todo, "id=? and uid=?", id, uid)
-
-	return todo
+	return store.GetTodo(uid, id)
 }
 
-func getUserId(w http.ResponseWriter) (int, error) {
-	// get the secret user id
-	data, err := ioutil.ReadFile("/tmp/secret.txt")
+func getUserId(w http.ResponseWriter, r *http.Request) (int, error) {
+	// get the user id from the bearer token
+	session, err := getSession(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		_, _ = w.Write([]byte("could not authenticate user"))
-		return 0, err
-	}
-	uid, err := strconv.Atoi(string(data))
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		_, _ = w.Write([]byte("could not authenticate user"))
 		return 0, err
 	}
 
-	return uid, nil
+	return session.UserID, nil
 }
 
-func StartServer() {
+// StartServer serves the API with the config, it refuses to start when
+// the database schema is behind
+func StartServer(cfg Config) error {
+	var err error
+	mode = cfg.Mode
+	urgencyWeights = cfg.Urgency
+	undoDepth = cfg.UndoDepth
+	if cfg.LogLevel == "debug" {
+		goLogger = goLogger.WithDebug()
+	}
+	if store, err = InitStore(cfg); err != nil {
+		return fmt.Errorf("could not connect to db: %w", err)
+	}
+	if err := CheckSchema(store); err != nil {
+		return err
+	}
+	if cfg.TrashRetention > 0 {
+		go purgeTrash(cfg.TrashRetention)
+	}
+
 	router := mux.NewRouter()
 	router.Path("/todos").HandlerFunc(TodoWithoutID)
 	router.Path("/users").Methods("POST").HandlerFunc(CreateUser)
 	router.Path("/users").Methods("GET").HandlerFunc(GETUser)
+	router.Path("/todos/search").Methods("GET").HandlerFunc(HandleSearch)
 	router.Path("/todos/{id}").HandlerFunc(TodoWithID)
+	router.Path("/todos/{id}/children").Methods("GET").HandlerFunc(HandleGETChildren)
+	router.Path("/todos/{id}/restore").Methods("POST").HandlerFunc(HandleRestore)
+	router.Path("/todos/{id}/history").Methods("GET").HandlerFunc(HandleGETHistory)
+	router.Path("/todos/{id}/revert").Methods("POST").HandlerFunc(HandleRevert)
+	router.Path("/trash").HandlerFunc(TrashWithoutID)
+	router.Path("/undo").Methods("POST").HandlerFunc(HandleUndo)
+	router.Path("/redo").Methods("POST").HandlerFunc(HandleRedo)
+	router.Path("/projects").HandlerFunc(ProjectWithoutID)
+	router.Path("/projects/{id}").HandlerFunc(ProjectWithID)
+	router.Path("/projects/{id}/todos").Methods("GET").HandlerFunc(HandleGETProjectTodos)
+	router.Path("/series/{id}").HandlerFunc(SeriesWithID)
+	router.Path("/tags").Methods("GET").HandlerFunc(HandleGETTags)
+	router.Path("/tags/{id}").Methods("PUT").HandlerFunc(HandleRenameTag)
+	router.Path("/sessions").HandlerFunc(SessionWithoutID)
+	router.Path("/sessions/{id}").Methods("DELETE").HandlerFunc(HandleDeleteSession)
 
-	fmt.Println("Listening on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	server := &http.Server{
+		Addr:         cfg.Listen,
+		Handler:      router,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	fmt.Println("Listening on " + cfg.Listen)
+	return server.ListenAndServe()
 }
@@ -3,13 +3,13 @@ package backend
 import (
 	"bytes"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"net/url"
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestCreateTodo(t *testing.T) {
@@ -37,9 +37,9 @@ func TestCreateTodo(t *testing.T) {
 		})
 
 		t.Run("todo has been stored into db", func(t *testing.T) {
-			var todo Todo
-			db.First(&todo, resBodyID)
-			if todo == (Todo{}) {
+			todo, err := store.GetTodo(uid, strconv.Itoa(int(resBodyID.(float64))))
+			assertRandomErr(t, err)
+			if todo.ID == 0 {
 				t.Errorf("didn't find the todo that was created earlier")
 			}
 		})
@@ -51,12 +51,17 @@ func TestCreateTodo(t *testing.T) {
 		}
 		res, _ := CreateTodoReq(reqBody)
 
-		got := res.Body.String()
-		want := ErrTodoReqBody
 		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
-		if got != want {
-			t.Error("didn't get proper response message on invalid req body")
-		}
+		assertAPIError(t, res, CodeInvalidTodo)
+	})
+
+	t.Run("text has to be a string", func(t *testing.T) {
+		req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader([]byte(`{"text": 42}`)))
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidTodo)
 	})
 }
 
@@ -67,7 +72,7 @@ func TestUserMiddleware(t *testing.T) {
 	// call the userMiddleware()
 	// check for errors
 	t.Run("does not throw error when valid user is present", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "http://localhost:8080/todos", nil)
+		req := NewAuthRequest("GET", "http://localhost:8080/todos", nil)
 		res := httptest.NewRecorder()
 		userMiddleware(res, req)
 
@@ -80,25 +85,14 @@ func TestUserMiddleware(t *testing.T) {
 	})
 
 	t.Run("throws error when no/invalid user is present", func(t *testing.T) {
-		// remove the current user
-		err := os.Remove("/tmp/secret.txt")
-		assertTestError(err)
-
+		// send the request without a session token
 		req := httptest.NewRequest("GET", "http://localhost:8080/todos", nil)
 		res := httptest.NewRecorder()
 		userMiddleware(res, req)
 
 		// should get proper error response code and body
 		assertStatusCode(t, res.Result().StatusCode, http.StatusUnauthorized)
-		got := res.Body.String()
-		want := ErrAuth
-
-		if got != want {
-			t.Errorf("expected an error on missing/invalid user")
-		}
-
-		// since we deleted the current user
-		initTestEnvironment()
+		assertAPIError(t, res, CodeUnauthorized)
 	})
 }
 
@@ -111,7 +105,7 @@ func TestGetTodos(t *testing.T) {
 	_ = addRandomUserAndTodo()
 
 	// get request
-	req := httptest.NewRequest("GET", "http://localhost:8080/todos", nil)
+	req := NewAuthRequest("GET", "http://localhost:8080/todos", nil)
 	res := httptest.NewRecorder()
 	TodoWithoutID(res, req)
 
@@ -125,7 +119,7 @@ func TestGetTodos(t *testing.T) {
 		}
 
 		for _, v := range resBody {
-			if v["uid"] != uid {
+			if int(v["uid"].(float64)) != uid {
 				t.Errorf("todo uid mismatch for this user, expected %v but got %v", uid, v["uid"])
 			}
 		}
@@ -147,7 +141,7 @@ func TestGetTodo(t *testing.T) {
 	t.Run("GET with valid id for current user", func(t *testing.T) {
 		// GET the todo
 		id := strconv.Itoa(int(resBody["id"].(float64)))
-		req := httptest.NewRequest("GET", "http://localhost:8080/todos/"+id, nil)
+		req := NewAuthRequest("GET", "http://localhost:8080/todos/"+id, nil)
 		res = httptest.NewRecorder()
 		TodoWithID(res, req)
 		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
@@ -163,15 +157,13 @@ func TestGetTodo(t *testing.T) {
 
 	t.Run("GET with invalid id", func(t *testing.T) {
 		// GET the todo
-		req := httptest.NewRequest("GET", "http://localhost:8080/todos/"+"-1", nil)
+		req := NewAuthRequest("GET", "http://localhost:8080/todos/"+"-1", nil)
 		res = httptest.NewRecorder()
 		TodoWithID(res, req)
 
 		// check the result
 		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
-		if res.Body.String() != ErrInvalidID {
-			t.Errorf("Expected error but got %#v", res.Body.String())
-		}
+		assertAPIError(t, res, CodeTodoNotFound)
 	})
 
 	t.Run("One User is not able to GET todo of others", func(t *testing.T) {
@@ -179,7 +171,7 @@ func TestGetTodo(t *testing.T) {
 		testUserAccess(t, func(todo map[string]interface{}) *httptest.ResponseRecorder {
 			id := strconv.Itoa(int(todo["id"].(float64)))
 			url := "http://localhost:8080/todos/" + id
-			req := httptest.NewRequest("GET", url, nil)
+			req := NewAuthRequest("GET", url, nil)
 			res := httptest.NewRecorder()
 			TodoWithID(res, req)
 
@@ -203,7 +195,7 @@ func TestUpdateTodo(t *testing.T) {
 		updatedTodo := map[string]string{"text": "yo adnan"}
 		reqJSONBody, _ := json.Marshal(updatedTodo)
 		res = httptest.NewRecorder()
-		req := httptest.NewRequest(
+		req := NewAuthRequest(
 			"PUT",
 			"http://localhost:8080/todos/"+id,
 			bytes.NewReader(reqJSONBody),
@@ -220,8 +212,8 @@ func TestUpdateTodo(t *testing.T) {
 		})
 
 		t.Run("Check if the todo got updated", func(t *testing.T) {
-			var todo Todo
-			db.First(&todo, "id=?", id)
+			todo, err := store.GetTodo(uid, id)
+			assertRandomErr(t, err)
 			t.Logf("%#v", todo)
 
 			if todo.Text != updatedTodo["text"] {
@@ -235,7 +227,7 @@ func TestUpdateTodo(t *testing.T) {
 		reqJSONBody, _ := json.Marshal(updatedTodo)
 
 		res := httptest.NewRecorder()
-		req := httptest.NewRequest(
+		req := NewAuthRequest(
 			"PUT",
 			"http://localhost:8080/todos/"+strconv.Itoa(-1),
 			bytes.NewReader(reqJSONBody),
@@ -243,12 +235,7 @@ func TestUpdateTodo(t *testing.T) {
 		TodoWithID(res, req)
 
 		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
-		got := res.Body.String()
-		want := ErrInvalidID
-
-		if got != want {
-			t.Errorf("Error message mismatch, wanted %#v, got %#v", want, got)
-		}
+		assertAPIError(t, res, CodeTodoNotFound)
 	})
 
 	t.Run("one user is not able to update another user's todo", func(t *testing.T) {
@@ -259,7 +246,7 @@ func TestUpdateTodo(t *testing.T) {
 			reqBody := map[string]interface{}{"text": "hello adnan"}
 			encodedReqBody, err := json.Marshal(reqBody)
 			assertTestError(err)
-			req := httptest.NewRequest("PUT", url, bytes.NewReader(encodedReqBody))
+			req := NewAuthRequest("PUT", url, bytes.NewReader(encodedReqBody))
 			res := httptest.NewRecorder()
 			TodoWithID(res, req)
 
@@ -280,7 +267,7 @@ func TestDeleteTodo(t *testing.T) {
 
 	t.Run("valid id", func(t *testing.T) {
 		// delete the todo
-		req := httptest.NewRequest("DELETE", "http://localhost:8080/todos/"+todoID, nil)
+		req := NewAuthRequest("DELETE", "http://localhost:8080/todos/"+todoID, nil)
 		res = httptest.NewRecorder()
 		TodoWithID(res, req)
 
@@ -295,8 +282,8 @@ func TestDeleteTodo(t *testing.T) {
 		})
 
 		t.Run("check db for todo deletion", func(t *testing.T) {
-			var todo Todo
-			db.First(todo, "id=?", todoID)
+			todo, err := store.GetTodo(uid, todoID)
+			assertRandomErr(t, err)
 			if todo.ID != 0 {
 				t.Errorf("Didn't expect todo to exist in the db")
 			}
@@ -304,17 +291,12 @@ func TestDeleteTodo(t *testing.T) {
 	})
 
 	t.Run("invalid id", func(t *testing.T) {
-		req := httptest.NewRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(-1), nil)
+		req := NewAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(-1), nil)
 		res = httptest.NewRecorder()
 		TodoWithID(res, req)
 
 		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
-		got := res.Body.String()
-		want := ErrInvalidID
-
-		if got != want {
-			t.Errorf("Didn't get proper error message")
-		}
+		assertAPIError(t, res, CodeTodoNotFound)
 	})
 
 	t.Run("one user is not able to delete another user's todo", func(t *testing.T) {
@@ -322,7 +304,7 @@ func TestDeleteTodo(t *testing.T) {
 			// send the request to /todos/todoId
 			id := strconv.Itoa(int(todo["id"].(float64)))
 			url := "http://localhost:8080/todos/" + id
-			req := httptest.NewRequest("DELETE", url, nil)
+			req := NewAuthRequest("DELETE", url, nil)
 			res := httptest.NewRecorder()
 			TodoWithID(res, req)
 
@@ -331,6 +313,142 @@ func TestDeleteTodo(t *testing.T) {
 	})
 }
 
+func TestCompleteTodo(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	// create two todos
+	res, _ := CreateTodoReq(nil)
+	var resBody map[string]interface{}
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	id := strconv.Itoa(int(resBody["id"].(float64)))
+	CreateTodoReq(nil)
+
+	completeTodo := func(completed bool) map[string]interface{} {
+		reqJSONBody, _ := json.Marshal(map[string]bool{"completed": completed})
+		req := NewAuthRequest("PUT", "http://localhost:8080/todos/"+id, bytes.NewReader(reqJSONBody))
+		res := httptest.NewRecorder()
+		TodoWithID(res, req)
+
+		return unmarshalAndAssert(t, res)
+	}
+
+	t.Run("mark as completed", func(t *testing.T) {
+		todo := completeTodo(true)
+		if todo["completed"] != true || todo["completed_at"] == nil {
+			t.Errorf("todo was not marked as completed, got %#v", todo)
+		}
+	})
+
+	t.Run("hide completed todos", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?completed=false", nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+
+		var todos []map[string]interface{}
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		if len(todos) != 1 || todos[0]["completed"] != false {
+			t.Errorf("expected only the open todo, got %#v", todos)
+		}
+	})
+
+	t.Run("mark as not completed", func(t *testing.T) {
+		todo := completeTodo(false)
+		if todo["completed"] != false || todo["completed_at"] != nil {
+			t.Errorf("todo was not marked as not completed, got %#v", todo)
+		}
+	})
+}
+
+func TestDueTodos(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	yesterday := time.Now().Add(-24 * time.Hour).Format(time.RFC3339)
+	tomorrow := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	CreateTodoReq(map[string]string{"text": "overdue", "due": yesterday})
+	CreateTodoReq(map[string]string{"text": "upcoming", "due": tomorrow})
+	CreateTodoReq(nil)
+
+	getTodos := func(query string) []map[string]interface{} {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?"+query, nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var todos []map[string]interface{}
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		return todos
+	}
+
+	t.Run("overdue todos", func(t *testing.T) {
+		todos := getTodos("overdue=true")
+		if len(todos) != 1 || todos[0]["text"] != "overdue" {
+			t.Errorf("expected only the overdue todo, got %#v", todos)
+		}
+	})
+
+	t.Run("todos due after now", func(t *testing.T) {
+		todos := getTodos("due_after=" + url.QueryEscape(time.Now().Format(time.RFC3339)))
+		if len(todos) != 1 || todos[0]["text"] != "upcoming" {
+			t.Errorf("expected only the upcoming todo, got %#v", todos)
+		}
+	})
+
+	t.Run("invalid due date", func(t *testing.T) {
+		res, _ := CreateTodoReq(map[string]string{"text": "invalid", "due": "tomorrow"})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidDue)
+	})
+}
+
+func TestPaginateTodos(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	for _, text := range []string{"d", "b", "e", "a", "c"} {
+		CreateTodoReq(map[string]string{"text": text})
+	}
+
+	t.Run("follow cursors through sorted pages", func(t *testing.T) {
+		var texts string
+		after := ""
+		for pages := 0; pages < 3; pages++ {
+			req := NewAuthRequest("GET", "http://localhost:8080/todos?limit=2&sort=text&order=desc&after="+after, nil)
+			res := httptest.NewRecorder()
+			TodoWithoutID(res, req)
+			assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+			if total := res.Header().Get("X-Total-Count"); total != "5" {
+				t.Errorf("expected a total count of 5, got %#v", total)
+			}
+			var todos []Todo
+			assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+			for _, todo := range todos {
+				texts += todo.Text
+			}
+
+			after = res.Header().Get("X-Next-Cursor")
+			if after == "" {
+				break
+			}
+		}
+
+		if texts != "edcba" || after != "" {
+			t.Errorf("expected all todos in descending order, got %#v", texts)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?limit=0", nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidPage)
+	})
+}
+
 // tests whether or not a user has unauthorized access to a route
 // accepts a func f as argument which is expected to make a request to the
 // route that is to be tested and return the response object
@@ -343,13 +461,8 @@ func testUserAccess(t *testing.T, f func(todo map[string]interface{}) *httptest.
 
 	// check if the output contains todos
 	// since this todo does not belong to the current user, nothing should be returned
-	got := res.Body.String()
-	want := ErrInvalidID
-
 	assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
-	if got != want {
-		t.Errorf("wanted %#v but got %#v", want, got)
-	}
+	assertAPIError(t, res, CodeTodoNotFound)
 }
 
 // create an arbitrary user, create a todo for him, then switch back to previous user
@@ -357,9 +470,11 @@ func testUserAccess(t *testing.T, f func(todo map[string]interface{}) *httptest.
 func addRandomUserAndTodo() map[string]interface{} {
 	// arbitrary user
 	user := User{Uname: "test", Pass: "test"}
-	db.Create(&user)
-	err := ioutil.WriteFile("/tmp/secret.txt", []byte(strconv.Itoa(user.ID)), 0644)
+	assertTestError(store.CreateUser(&user))
+	session, err := NewSession(user.ID, "", "")
 	assertTestError(err)
+	mainToken := token
+	token = session.Token
 	// create todo for that user
 	res, _ := CreateTodoReq(nil)
 	// unmarshall the todo
@@ -367,8 +482,7 @@ func addRandomUserAndTodo() map[string]interface{} {
 	err = json.Unmarshal(res.Body.Bytes(), &todo)
 	assertTestError(err)
 	// switch back to our main user
-	err = ioutil.WriteFile("/tmp/secret.txt", []byte(strconv.Itoa(uid)), 0644)
-	assertTestError(err)
+	token = mainToken
 
 	return todo
 }
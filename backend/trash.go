@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// trashPurgeInterval is how often the trash is checked for todos past the
+// retention period
+const trashPurgeInterval = time.Hour
+
+func TrashWithoutID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		HandleGETTrash(w, r)
+	case "DELETE":
+		HandleEmptyTrash(w, r)
+	}
+}
+
+func HandleGETTrash(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	todos, err := store.ListTrash(uid)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	encodedResBody, _ := json.Marshal(todos)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+func HandleEmptyTrash(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	purged, err := store.EmptyTrash(uid)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("Successfully deleted " + strconv.FormatInt(purged, 10) + " todos for good"))
+}
+
+// HandleRestore takes a todo out of the trash along with the todos below
+// it which were deleted with it
+func HandleRestore(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	todo, err := store.GetDeletedTodo(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if todo.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return
+	}
+	err = store.RestoreTodo(&todo)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	todo.Urgency = urgencyWeights.Urgency(todo, time.Now())
+	if !assertServerError(setProgress(&todo), w) {
+		return
+	}
+	encodedResBody, _ := json.Marshal(todo)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// purgeTrash deletes the todos which have been in the trash for longer than
+// the retention period, right away and then every trashPurgeInterval
+func purgeTrash(retention time.Duration) {
+	for {
+		purged, err := store.PurgeTrash(time.Now().Add(-retention))
+		if err != nil {
+			goLogger.Error(err)
+		} else if purged > 0 {
+			goLogger.Infof("purged %d todos from the trash", purged)
+		}
+		time.Sleep(trashPurgeInterval)
+	}
+}
@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	getTrash := func() []Todo {
+		res := SendAuthRequest("GET", "http://localhost:8080/trash", "", TrashWithoutID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		var todos []Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		return todos
+	}
+	countTodos := func() int {
+		todos, total, _, err := store.ListTodos(uid, TodoFilter{}, TodoPage{limit: defaultPageLimit, sort: "created"})
+		assertRandomErr(t, err)
+		if int(total) != len(todos) {
+			t.Errorf("expected the total count %d to match the %d todos", total, len(todos))
+		}
+		return len(todos)
+	}
+
+	parent := CreateTestTodo(t, `{"text": "move out", "tags": ["home"]}`)
+	child := CreateTestTodo(t, `{"text": "pack boxes", "parent_id": `+strconv.Itoa(parent.ID)+`}`)
+	CreateTestTodo(t, `{"text": "keep me"}`)
+
+	t.Run("deleted todos go to the trash", func(t *testing.T) {
+		res := SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(parent.ID)+"?children=delete", "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		if count := countTodos(); count != 1 {
+			t.Errorf("expected only one todo left in the listing, got %d", count)
+		}
+		tags, err := store.ListTagCounts(uid)
+		assertRandomErr(t, err)
+		if len(tags) != 1 || tags[0].Count != 0 {
+			t.Errorf("expected the todos in the trash not to be counted, got %#v", tags)
+		}
+		trash := getTrash()
+		if len(trash) != 2 || !trash[0].DeletedAt.Valid {
+			t.Errorf("expected both todos in the trash, got %#v", trash)
+		}
+	})
+
+	t.Run("restore a todo with its children", func(t *testing.T) {
+		res := SendAuthRequest("POST", "http://localhost:8080/todos/"+strconv.Itoa(parent.ID)+"/restore", "", HandleRestore)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var restored Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &restored))
+		if restored.DeletedAt.Valid || len(restored.Tags) != 1 || restored.Progress == nil || restored.Progress.Total != 1 {
+			t.Errorf("expected the todo back with its tag and child, got %#v", restored)
+		}
+		if count := countTodos(); count != 3 || len(getTrash()) != 0 {
+			t.Errorf("expected every todo back, got %d", count)
+		}
+	})
+
+	t.Run("restore a todo that is not in the trash", func(t *testing.T) {
+		res := SendAuthRequest("POST", "http://localhost:8080/todos/"+strconv.Itoa(parent.ID)+"/restore", "", HandleRestore)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+		assertAPIError(t, res, CodeTodoNotFound)
+	})
+
+	t.Run("a restored child of a purged todo becomes a top level todo", func(t *testing.T) {
+		res := SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(child.ID), "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		res = SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(parent.ID), "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		purged, err := store.PurgeTrash(time.Now().Add(time.Second))
+		assertRandomErr(t, err)
+		if purged != 2 {
+			t.Fatalf("expected both todos to be purged, got %d", purged)
+		}
+		if len(getTrash()) != 0 {
+			t.Errorf("expected an empty trash")
+		}
+	})
+
+	t.Run("empty the trash", func(t *testing.T) {
+		todo := CreateTestTodo(t, `{"text": "typo"}`)
+		SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID), "", TodoWithID)
+
+		res := SendAuthRequest("DELETE", "http://localhost:8080/trash", "", TrashWithoutID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		if got := res.Body.String(); got != "Successfully deleted 1 todos for good" {
+			t.Errorf("unexpected message %#v", got)
+		}
+		res = SendAuthRequest("POST", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID)+"/restore", "", HandleRestore)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Progress counts the completed todos below a todo, at every depth
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// subtreeQuery selects the ids of every todo below the todo, its
+// arguments are the id of the todo and the user id
+const subtreeQuery = `WITH RECURSIVE subtree(id) AS (
+	SELECT id FROM todos WHERE parent_id = ? AND uid = ?
+	UNION ALL
+	SELECT todos.id FROM todos JOIN subtree ON todos.parent_id = subtree.id
+) SELECT id FROM subtree`
+
+// HandleGETChildren sends the children of a todo with their progress,
+// subtree=true nests every todo below the todo under its parent
+func HandleGETChildren(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	todo, err := store.GetTodo(uid, ExtractID(r))
+	if !assertServerError(err, w) {
+		return
+	}
+	if todo.ID == 0 {
+		writeError(w, http.StatusNotFound, CodeTodoNotFound, ErrInvalidID)
+		return
+	}
+	descendants, err := store.ListSubtree(uid, todo.ID)
+	if !assertServerError(err, w) {
+		return
+	}
+
+	children := buildTree(todo.ID, descendants)
+	if r.URL.Query().Get("subtree") != "true" {
+		for i := range children {
+			children[i].Children = nil
+		}
+	}
+
+	encodedResBody, _ := json.Marshal(children)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encodedResBody)
+}
+
+// buildTree nests the descendants of the todo with the given id under
+// their parents, rolls up their progress and scores their urgency
+func buildTree(id int, descendants []Todo) []Todo {
+	setUrgency(descendants)
+	byParent := map[int][]Todo{}
+	for _, todo := range descendants {
+		byParent[*todo.ParentID] = append(byParent[*todo.ParentID], todo)
+	}
+
+	var build func(parentID int) []Todo
+	build = func(parentID int) []Todo {
+		children := byParent[parentID]
+		for i := range children {
+			children[i].Children = build(children[i].ID)
+			children[i].Progress = rollUp(children[i].Children)
+		}
+		if children == nil {
+			return []Todo{}
+		}
+		return children
+	}
+	return build(id)
+}
+
+// rollUp counts the children and everything below them, nil means there
+// are no children
+func rollUp(children []Todo) *Progress {
+	if len(children) == 0 {
+		return nil
+	}
+	progress := &Progress{}
+	for _, child := range children {
+		progress.Total++
+		if child.Completed {
+			progress.Done++
+		}
+		if child.Progress != nil {
+			progress.Total += child.Progress.Total
+			progress.Done += child.Progress.Done
+		}
+	}
+	return progress
+}
+
+// setProgress rolls up the progress of a single todo
+func setProgress(todo *Todo) error {
+	descendants, err := store.ListSubtree(todo.UserID, todo.ID)
+	if err != nil {
+		return err
+	}
+	todo.Progress = rollUp(buildTree(todo.ID, descendants))
+	return nil
+}
+
+// parseParentID reads the id of the parent todo from a decoded request body,
+// null results in a nil id which makes the todo a top level todo. The
+// parent can't be the todo with the id itself or one below it.
+func parseParentID(uid, id int, value interface{}) (*int, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parentID, ok := value.(float64)
+	if !ok {
+		return nil, errors.New(ErrInvalidParent)
+	}
+	parent, err := store.GetTodo(uid, strconv.Itoa(int(parentID)))
+	if err != nil {
+		return nil, err
+	}
+	if parent.ID == 0 || parent.ID == id {
+		return nil, errors.New(ErrInvalidParent)
+	}
+	if id != 0 {
+		descendants, err := store.ListSubtree(uid, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, descendant := range descendants {
+			if descendant.ID == parent.ID {
+				return nil, errors.New(ErrInvalidParent)
+			}
+		}
+	}
+
+	return &parent.ID, nil
+}
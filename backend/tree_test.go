@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestTodoTree(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	getChildren := func(todo Todo, query string) []Todo {
+		res := SendAuthRequest("GET", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID)+"/children"+query, "", HandleGETChildren)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+		var children []Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &children))
+		return children
+	}
+
+	// trip
+	// ├── flights (done)
+	// │   └── compare prices
+	// └── hotel
+	trip := CreateTestTodo(t, `{"text": "trip"}`)
+	flights := CreateTestTodo(t, `{"text": "flights", "parent_id": `+strconv.Itoa(trip.ID)+`}`)
+	prices := CreateTestTodo(t, `{"text": "compare prices", "parent_id": `+strconv.Itoa(flights.ID)+`}`)
+	hotel := CreateTestTodo(t, `{"text": "hotel", "parent_id": `+strconv.Itoa(trip.ID)+`}`)
+	res := SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(flights.ID), `{"completed": true}`, TodoWithID)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+	t.Run("progress is rolled up", func(t *testing.T) {
+		res := SendAuthRequest("GET", "http://localhost:8080/todos/"+strconv.Itoa(trip.ID), "", TodoWithID)
+		var todo Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+		if todo.Progress == nil || *todo.Progress != (Progress{Done: 1, Total: 3}) {
+			t.Errorf("expected 1 of 3 todos done, got %#v", todo.Progress)
+		}
+	})
+
+	t.Run("children", func(t *testing.T) {
+		children := getChildren(trip, "")
+		if len(children) != 2 || children[0].ID != flights.ID || children[1].ID != hotel.ID {
+			t.Fatalf("expected flights and hotel, got %#v", children)
+		}
+		if children[0].Children != nil || children[0].Progress == nil || children[0].Progress.Total != 1 {
+			t.Errorf("expected the progress of flights without its children, got %#v", children[0])
+		}
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+		children := getChildren(trip, "?subtree=true")
+		if len(children) != 2 || len(children[0].Children) != 1 || children[0].Children[0].ID != prices.ID {
+			t.Errorf("expected compare prices below flights, got %#v", children)
+		}
+	})
+
+	t.Run("a todo can't move below itself", func(t *testing.T) {
+		res := SendAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(trip.ID), `{"parent_id": `+strconv.Itoa(prices.ID)+`}`, TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+		assertAPIError(t, res, CodeInvalidParent)
+	})
+
+	t.Run("deleting a todo moves its children up", func(t *testing.T) {
+		res := SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(flights.ID), "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		children := getChildren(trip, "")
+		if len(children) != 2 || children[0].ID != prices.ID || children[1].ID != hotel.ID {
+			t.Errorf("expected compare prices to move up to trip, got %#v", children)
+		}
+	})
+
+	t.Run("deleting a todo with its children", func(t *testing.T) {
+		res := SendAuthRequest("DELETE", "http://localhost:8080/todos/"+strconv.Itoa(trip.ID)+"?children=delete", "", TodoWithID)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		for _, todo := range []Todo{trip, prices, hotel} {
+			found, err := store.GetTodo(uid, strconv.Itoa(todo.ID))
+			assertRandomErr(t, err)
+			if found.ID != 0 {
+				t.Errorf("expected %s to be deleted", todo.Text)
+			}
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Priorities of a todo, they can be given by name or number
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// PriorityNames are the names of the priorities, indexed by priority
+var PriorityNames = []string{"none", "low", "medium", "high"}
+
+// UrgencyWeights weigh the parts of the urgency score, a weight is the
+// most a part can add to the score
+type UrgencyWeights struct {
+	Priority float64 `mapstructure:"priority"`
+	Due      float64 `mapstructure:"due"`
+	Age      float64 `mapstructure:"age"`
+	Tags     float64 `mapstructure:"tags"`
+}
+
+// urgencyWeights are set from the config when the server starts
+var urgencyWeights = UrgencyWeights{Priority: 6, Due: 12, Age: 2, Tags: 1}
+
+// urgencyMaxAge is the age after which a todo gets no more urgent
+const urgencyMaxAge = 365 * 24 * time.Hour
+
+// Urgency scores how urgent an open todo is, completed todos score 0.
+// The score is the sum of
+//   - the priority, scaled so high gets the whole weight
+//   - the due date, from a fifth of the weight two weeks ahead up to the
+//     whole weight a week overdue, todos without one get nothing
+//   - the age, growing to the whole weight over a year
+//   - the tags, 0.8 of the weight for one tag up to the whole weight for three
+func (w UrgencyWeights) Urgency(todo Todo, now time.Time) float64 {
+	if todo.Completed {
+		return 0
+	}
+
+	urgency := w.Priority * float64(todo.Priority) / PriorityHigh
+	if todo.Due != nil {
+		overdue := now.Sub(*todo.Due).Hours() / 24
+		switch {
+		case overdue >= 7:
+			urgency += w.Due
+		case overdue >= -14:
+			urgency += w.Due * ((overdue+14)*0.8/21 + 0.2)
+		default:
+			urgency += w.Due * 0.2
+		}
+	}
+	if !todo.CreatedAt.IsZero() {
+		age := now.Sub(todo.CreatedAt)
+		if age > urgencyMaxAge {
+			age = urgencyMaxAge
+		}
+		urgency += w.Age * float64(age) / float64(urgencyMaxAge)
+	}
+	switch len(todo.Tags) {
+	case 0:
+	case 1:
+		urgency += w.Tags * 0.8
+	case 2:
+		urgency += w.Tags * 0.9
+	default:
+		urgency += w.Tags
+	}
+
+	return math.Round(urgency*100) / 100
+}
+
+// setUrgency scores the todos with the configured weights
+func setUrgency(todos []Todo) {
+	now := time.Now()
+	for i := range todos {
+		todos[i].Urgency = urgencyWeights.Urgency(todos[i], now)
+	}
+}
+
+// ParsePriority reads a priority name like high or a number from 0 to 3
+func ParsePriority(value string) (int, error) {
+	for priority, name := range PriorityNames {
+		if strings.EqualFold(value, name) {
+			return priority, nil
+		}
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil || priority < PriorityNone || priority > PriorityHigh {
+		return 0, errors.New(ErrInvalidPriority)
+	}
+
+	return priority, nil
+}
+
+// parsePriority reads the priority of a decoded request body, which is
+// either a name or a number
+func parsePriority(value interface{}) (int, error) {
+	switch value := value.(type) {
+	case nil:
+		return PriorityNone, nil
+	case string:
+		return ParsePriority(value)
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, errors.New(ErrInvalidPriority)
+		}
+		return ParsePriority(strconv.Itoa(int(value)))
+	}
+
+	return 0, errors.New(ErrInvalidPriority)
+}
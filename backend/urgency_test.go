@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUrgency(t *testing.T) {
+	now := time.Now()
+	weights := UrgencyWeights{Priority: 6, Due: 12, Age: 2, Tags: 1}
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	cases := map[string]struct {
+		todo Todo
+		want float64
+	}{
+		"nothing":             {Todo{}, 0},
+		"high priority":       {Todo{Priority: PriorityHigh}, 6},
+		"low priority":        {Todo{Priority: PriorityLow}, 2},
+		"a week overdue":      {Todo{Due: at(-7 * 24 * time.Hour)}, 12},
+		"due now":             {Todo{Due: at(0)}, 12 * (14*0.8/21 + 0.2)},
+		"due in a month":      {Todo{Due: at(30 * 24 * time.Hour)}, 12 * 0.2},
+		"a year old":          {Todo{CreatedAt: now.Add(-2 * urgencyMaxAge)}, 2},
+		"one tag":             {Todo{Tags: []Tag{{Name: "a"}}}, 0.8},
+		"three tags":          {Todo{Tags: []Tag{{Name: "a"}, {Name: "b"}, {Name: "c"}}}, 1},
+		"completed high todo": {Todo{Priority: PriorityHigh, Completed: true}, 0},
+	}
+	for name, c := range cases {
+		if got := weights.Urgency(c.todo, now); got-c.want > 0.01 || c.want-got > 0.01 {
+			t.Errorf("%s: expected an urgency of %.2f, got %.2f", name, c.want, got)
+		}
+	}
+}
+
+func TestTodoPriority(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	createTodo := func(body string) *httptest.ResponseRecorder {
+		req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader([]byte(body)))
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+		return res
+	}
+
+	t.Run("by name or number", func(t *testing.T) {
+		for body, want := range map[string]int{
+			`{"text": "named", "priority": "High"}`: PriorityHigh,
+			`{"text": "numbered", "priority": 1}`:   PriorityLow,
+			`{"text": "none"}`:                      PriorityNone,
+		} {
+			res := createTodo(body)
+			assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+			var todo Todo
+			assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+			if todo.Priority != want {
+				t.Errorf("expected priority %d for %s, got %d", want, body, todo.Priority)
+			}
+		}
+	})
+
+	t.Run("invalid priority", func(t *testing.T) {
+		for _, body := range []string{`{"text": "a", "priority": "urgent"}`, `{"text": "a", "priority": 4}`, `{"text": "a", "priority": 1.5}`} {
+			res := createTodo(body)
+			assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
+			assertAPIError(t, res, CodeInvalidPriority)
+		}
+	})
+
+	t.Run("update the priority", func(t *testing.T) {
+		var todo Todo
+		assertRandomErr(t, json.Unmarshal(createTodo(`{"text": "later"}`).Body.Bytes(), &todo))
+
+		req := NewAuthRequest("PUT", "http://localhost:8080/todos/"+strconv.Itoa(todo.ID), bytes.NewReader([]byte(`{"priority": "medium"}`)))
+		res := httptest.NewRecorder()
+		TodoWithID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+		if todo.Priority != PriorityMedium || todo.Urgency == 0 {
+			t.Errorf("expected a medium priority todo with an urgency, got %#v", todo)
+		}
+	})
+}
+
+func TestSortByUrgency(t *testing.T) {
+	initTestEnvironment()
+	defer cleanTestEnvironment()
+
+	overdue := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	CreateTodoReq(map[string]string{"text": "b"})
+	CreateTodoReq(map[string]string{"text": "a", "priority": "high", "due": overdue})
+	CreateTodoReq(map[string]string{"text": "d"})
+	CreateTodoReq(map[string]string{"text": "c", "priority": "low"})
+
+	var texts string
+	after := ""
+	for pages := 0; pages < 3; pages++ {
+		req := NewAuthRequest("GET", "http://localhost:8080/todos?limit=2&sort=urgency&after="+after, nil)
+		res := httptest.NewRecorder()
+		TodoWithoutID(res, req)
+		assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+
+		var todos []Todo
+		assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todos))
+		for _, todo := range todos {
+			texts += todo.Text
+		}
+
+		after = res.Header().Get("X-Next-Cursor")
+		if after == "" {
+			break
+		}
+	}
+
+	// todos of the same urgency are in descending order of their ids
+	if texts != "acdb" || after != "" {
+		t.Errorf("expected the most urgent todos first, got %#v", texts)
+	}
+}
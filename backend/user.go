@@ -1,18 +1,26 @@
 package backend
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
 	"io/ioutil"
 	"net/http"
 )
 
 type User struct {
 	Uname string `json:"uname"`
-	Pass  string `json:"pass"`
+	Pass  string `json:"-"`
 	Todos []Todo `json:"todos"`
 	ID    int    `gorm:"primaryKey" json:"id"`
+	Token string `gorm:"-" json:"token,omitempty"`
 }
 
+// errUsernameTaken is returned when a user is created with a username
+// which is in use
+var errUsernameTaken = errors.New(ErrUsernameTaken)
+
 func CreateUser(w http.ResponseWriter, r *http.Request) {
 	// get the req body
 	reqBody, _ := ioutil.ReadAll(r.Body)
@@ -23,16 +31,27 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	err := json.Unmarshal(reqBody, &decodedReqBody)
 	if decodedReqBody.Uname == "" || decodedReqBody.Pass == "" || err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte(ErrUserReqBody))
+		writeError(w, http.StatusBadRequest, CodeInvalidUser, ErrUserReqBody)
+		return
+	}
+	// hash the password and insert into db
+	hash, err := hashPassword(decodedReqBody.Pass)
+	if !assertServerError(err, w) {
 		return
 	}
-	// insert into db
 	user := User{
 		Uname: decodedReqBody.Uname,
-		Pass:  decodedReqBody.Pass,
+		Pass:  hash,
+	}
+	err = store.CreateUser(&user)
+	// usernames are unique, a login has to find a single user
+	if errors.Is(err, errUsernameTaken) {
+		writeError(w, http.StatusConflict, CodeUsernameTaken, ErrUsernameTaken)
+		return
+	}
+	if !assertServerError(err, w) {
+		return
 	}
-	db.Create(&user)
 
 	// marshall and send
 	encodedResBody, _ := json.Marshal(user)
@@ -52,28 +71,85 @@ func GETUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uname := decodedResBody["uname"]
-	pass := decodedResBody["pass"]
+	uname, _ := decodedResBody["uname"].(string)
+	pass, _ := decodedResBody["pass"].(string)
 
-	if uname == nil || pass == nil {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = w.Write([]byte(ErrUserReqBody))
+	if uname == "" || pass == "" {
+		writeError(w, http.StatusNotFound, CodeInvalidCredentials, ErrUserReqBody)
 		return
 	}
 
-	// get the uname and pass
-	// query the db with uname and pass
-	var user User
-	db.First(&user, "uname=? and pass=?", uname, pass)
-	if user.ID == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		_, _ = w.Write([]byte(ErrUserReqBody))
+	// query the db with uname and verify the pass
+	user, err := store.GetUserByName(uname)
+	if !assertServerError(err, w) {
+		return
+	}
+	ok := false
+	if user.ID != 0 {
+		ok, err = checkPassword(&user, pass)
+		if !assertServerError(err, w) {
+			return
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, CodeInvalidCredentials, ErrUserReqBody)
 		return
 	}
 
+	// start a new session for the user
+	client, _ := decodedResBody["client"].(string)
+	session, err := NewSession(user.ID, r.UserAgent(), client)
+	if !assertServerError(err, w) {
+		return
+	}
+	user.Token = session.Token
+
 	// marshall and send
 	resBody, _ := json.Marshal(user)
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(resBody)
 
 }
+
+// hashPassword hashes the password with bcrypt, which salts every hash
+func hashPassword(pass string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// checkPassword reports whether pass matches the stored password of the user.
+// Passwords stored in plaintext by older versions are hashed and saved
+// on the first successful check
+func checkPassword(user *User, pass string) (bool, error) {
+	if isPasswordHash(user.Pass) {
+		err := bcrypt.CompareHashAndPassword([]byte(user.Pass), []byte(pass))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	// legacy plaintext password
+	if subtle.ConstantTimeCompare([]byte(user.Pass), []byte(pass)) != 1 {
+		return false, nil
+	}
+	hash, err := hashPassword(pass)
+	if err != nil {
+		return false, err
+	}
+	user.Pass = hash
+	if err := store.UpdatePassword(user); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func isPasswordHash(pass string) bool {
+	_, err := bcrypt.Cost([]byte(pass))
+	return err == nil
+}
@@ -3,7 +3,6 @@ package backend
 import (
 	"bytes"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,11 +23,28 @@ func TestAddUser(t *testing.T) {
 		decodedResBody := unmarshalAndAssert(t, res)
 
 		// check if the user was actually created
-		var user User
-		db.First(&user, "id=?", decodedResBody["id"])
+		user, err := store.GetUser(int(decodedResBody["id"].(float64)))
+		assertRandomErr(t, err)
 		if user.Uname != reqBody["uname"] {
 			t.Errorf("User was not created")
 		}
+
+		// check if the password was hashed and not sent back
+		if user.Pass == reqBody["pass"] || !isPasswordHash(user.Pass) {
+			t.Errorf("Password was not hashed")
+		}
+		if _, ok := decodedResBody["pass"]; ok {
+			t.Errorf("Didn't expect the password in the response body")
+		}
+	})
+
+	t.Run("on a taken username", func(t *testing.T) {
+		res, _ := RequestCreateUser(map[string]string{"uname": "adnan", "pass": "other"})
+		assertStatusCode(t, res.Result().StatusCode, http.StatusConflict)
+		assertAPIError(t, res, CodeUsernameTaken)
+		if _, err := store.GetUserByName("adnan"); err != nil {
+			t.Errorf("expected the first user to stay, got %v", err)
+		}
 	})
 
 	t.Run("on invalid req body", func(t *testing.T) {
@@ -38,13 +54,8 @@ func TestAddUser(t *testing.T) {
 		res, _ := RequestCreateUser(reqBody)
 
 		// check response status and body text
-		got := res.Body.String()
-		want := ErrUserReqBody
 		assertStatusCode(t, res.Result().StatusCode, http.StatusBadRequest)
-
-		if got != want {
-			t.Errorf("Response body does not adhere to req body")
-		}
+		assertAPIError(t, res, CodeInvalidUser)
 	})
 }
 
@@ -77,6 +88,33 @@ func TestGETUser(t *testing.T) {
 		if decodedResBody["uname"] != reqBody["uname"] {
 			t.Errorf("Did not GET the user as expected")
 		}
+
+		// check if a session was started for the user
+		session, err := store.GetSession(decodedResBody["token"].(string))
+		assertRandomErr(t, err)
+		if session.UserID != int(decodedResBody["id"].(float64)) {
+			t.Errorf("Did not get a valid session token with the user")
+		}
+	})
+
+	t.Run("plaintext password gets upgraded on login", func(t *testing.T) {
+		legacyUser := User{Uname: "legacy", Pass: "plaintext"}
+		assertTestError(store.CreateUser(&legacyUser))
+
+		// log in with the plaintext password
+		getReqBody, err := json.Marshal(map[string]string{"uname": "legacy", "pass": "plaintext"})
+		assertTestError(err)
+		req := httptest.NewRequest("GET", "http://localhost:8080/users", bytes.NewReader(getReqBody))
+		res = httptest.NewRecorder()
+		GETUser(res, req)
+		unmarshalAndAssert(t, res)
+
+		// check if the stored password got hashed
+		user, err := store.GetUser(legacyUser.ID)
+		assertRandomErr(t, err)
+		if !isPasswordHash(user.Pass) {
+			t.Errorf("Plaintext password was not upgraded")
+		}
 	})
 
 	t.Run("invalid req body", func(t *testing.T) {
@@ -93,11 +131,7 @@ func TestGETUser(t *testing.T) {
 		GETUser(res, req)
 		assertStatusCode(t, res.Result().StatusCode, http.StatusNotFound)
 
-		got := res.Body.String()
-		want := ErrUserReqBody
-		if got != want {
-			t.Errorf("didn't get proper response, wanted %s but got %s", want, got)
-		}
+		assertAPIError(t, res, CodeInvalidCredentials)
 	})
 }
 
@@ -111,11 +145,3 @@ func RequestCreateUser(reqBody interface{}) (*httptest.ResponseRecorder, *http.R
 
 	return res, req
 }
-
-func checkIfSecretFileStored(t *testing.T) {
-	// check if there is a secret file stored
-	_, err := ioutil.ReadFile("/tmp/secret.txt")
-	if err != nil {
-		t.Errorf("Secret file has not been stored")
-	}
-}
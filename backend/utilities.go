@@ -6,50 +6,87 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	goLog "github.com/withmandala/go-log"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-	"io/ioutil"
-	"log"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strconv"
 	"testing"
 )
 
 var (
-	goLogger       = goLog.New(os.Stderr).WithColor()
-	mode           = "prod"
-	db             = InitDB()
-	ErrTodoReqBody = "invalid request body, please include a text field with non-zero length"
-	ErrUserReqBody = "invalid request body, must have a valid uname and pass field"
-	ErrInvalidID   = "invalid id"
-	ErrInternal    = "please try again later"
-	ErrAuth        = "could not authenticate user"
-	uid            = 0
+	goLogger             = goLog.New(os.Stderr).WithColor()
+	mode                 = "prod"
+	store                Store
+	ErrTodoReqBody       = "invalid request body, please include a text field with non-zero length"
+	ErrTodoUpdateReqBody = "invalid request body, please include a text, completed, due, tags, project_id, priority, repeat or parent_id field"
+	ErrUserReqBody       = "invalid request body, must have a valid uname and pass field"
+	ErrProjectReqBody    = "invalid request body, please include a name field with non-zero length"
+	ErrInvalidProject    = "invalid project id"
+	ErrTagReqBody        = "invalid tags, please use non-empty names that don't start with -"
+	ErrInvalidDue        = "invalid due date, please use the RFC 3339 format"
+	ErrInvalidPriority   = "invalid priority, please use none, low, medium, high or a number from 0 to 3"
+	ErrInvalidParent     = "invalid parent, please use the id of another todo which is not below this one"
+	ErrInvalidRepeat     = "invalid repeat, please use a rule like FREQ=WEEKLY;BYDAY=MO"
+	ErrSeriesReqBody     = "invalid request body, please include a repeat field, null stops the series"
+	ErrSeriesNotOpen     = "the series has no open todo"
+	ErrRevertReqBody     = "invalid request body, please include the id of a revision as to"
+	ErrInvalidRevision   = "the todo has no revision with that id"
+	ErrNothingToUndo     = "there is nothing to undo"
+	ErrNothingToRedo     = "there is nothing to redo"
+	ErrJournalConflict   = "the todo was changed in the meantime, please check it and change it by hand"
+	ErrJournalBusy       = "another undo or redo ran at the same time, please try again"
+	ErrPatchType         = "unsupported patch, please send application/merge-patch+json or application/json-patch+json"
+	ErrPatchReqBody      = "invalid patch, please send a JSON document"
+	ErrStaleTodo         = "the todo was changed since it was read, please fetch it again"
+	ErrInvalidPage       = "invalid pagination, please check the limit, after, sort and order params"
+	ErrSearchQuery       = "invalid search, please include a q param with at least one word"
+	ErrInvalidID         = "invalid id"
+	ErrInternal          = "please try again later"
+	ErrAuth              = "could not authenticate user"
+	ErrUsernameTaken     = "the username is taken, please pick another one"
+	uid                  = 0
+	token                = ""
+	testDir              = ""
+	testDBCount          = 0
 )
 
 // initialize the testing environment for subsequent tests
 func initTestEnvironment() {
-	TruncateTable(&Todo{})
-	TruncateTable(&User{})
+	cleanTestEnvironment()
 	// create the user
 	user := User{Uname: "adnan", Pass: "badshah"}
-	db.Create(&user)
+	assertTestError(store.CreateUser(&user))
 	uid = user.ID
-	// create the secret file
-	err := ioutil.WriteFile("/tmp/secret.txt", []byte(strconv.Itoa(user.ID)), 0644)
+	// create the session
+	session, err := NewSession(user.ID, "", "")
 	assertTestError(err)
+	token = session.Token
 }
 
-// clean the testing environment
+// clean the testing environment by switching to an empty sqlite store
+// in a temp file
 func cleanTestEnvironment() {
-	TruncateTable(&User{})
-	TruncateTable(&Todo{})
-	// remove secret file
-	_ = os.Remove("/tmp/secret.txt")
+	var err error
+	if testDir == "" {
+		testDir, err = os.MkdirTemp("", "todo-cli")
+		assertTestError(err)
+	}
+	if old, ok := store.(*SQLiteStore); ok {
+		if sqlDB, err := old.db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+
+	testDBCount++
+	s, err := NewSQLiteStore(filepath.Join(testDir, fmt.Sprintf("test-%d.db", testDBCount)), logger.Silent)
+	assertTestError(err)
+	assertTestError(s.MigrateUp())
+	store = s
+	// forget the session token
+	token = ""
 }
 
 func assertRandomErr(t *testing.T, err interface{}) {
@@ -65,60 +102,42 @@ func assertStatusCode(t *testing.T, got, want int) {
 	}
 }
 
-func TruncateTable(t interface{}) {
-	db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(t)
-}
-
 func ExtractID(r *http.Request) string {
 	var id string
 
 	if mode == "prod" {
 		id = mux.Vars(r)["id"]
 	} else {
-		re := regexp.MustCompile(`/(todos|users)/(.*)`)
+		re := regexp.MustCompile(`/(todos|users|tags|projects|sessions|series)/([^/]*)`)
 		id = string(re.FindSubmatch([]byte(r.URL.Path))[2])
 	}
 
 	return id
 }
 
-func InitDB() *gorm.DB {
-	// connect to db
-	var dbname string
-	if mode == "prod" {
-		dbname = "todo_cli"
-	} else {
-		dbname = "todo_cli_test"
-	}
-	dsn := fmt.Sprintf("host=localhost user=kmab password=kmab dbname=%s port=5432", dbname)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.New(
-			log.New(os.Stdout, "\r\n", log.LstdFlags),
-			logger.Config{
-				LogLevel:                  logger.Silent, // Log level
-				IgnoreRecordNotFoundError: true,          // Ignore ErrRecordNotFound error for logger
-				Colorful:                  true,          // Disable color
-			},
-		),
-	})
-
+func assertTestError(err error) {
 	if err != nil {
-		log.Fatalf("Could not connect to db")
+		panic(err)
 	}
-	return db
 }
 
-func assertTestError(err error) {
-	if err != nil {
-		panic(err)
+// assertAPIError checks that the response is an error with the code
+func assertAPIError(t *testing.T, res *httptest.ResponseRecorder, code string) *APIError {
+	t.Helper()
+	var resBody ErrorResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &resBody); err != nil || resBody.Error == nil {
+		t.Fatalf("expected an error response but got %#v", res.Body.String())
+	}
+	if resBody.Error.Code != code {
+		t.Errorf("expected error code %#v but got %#v", code, resBody.Error.Code)
 	}
+
+	return resBody.Error
 }
 
 func assertServerError(err error, w http.ResponseWriter) bool {
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte(ErrInternal))
+		writeError(w, http.StatusInternalServerError, CodeInternal, ErrInternal)
 		return false
 	}
 	return true
@@ -133,13 +152,38 @@ func CreateTodoReq(reqBody map[string]string) (*httptest.ResponseRecorder, *http
 		}
 	}
 	reqJSONBody, _ := json.Marshal(reqBody)
-	req := httptest.NewRequest("POST", "http://localhost:8080/todos", bytes.NewReader(reqJSONBody))
+	req := NewAuthRequest("POST", "http://localhost:8080/todos", bytes.NewReader(reqJSONBody))
 	res := httptest.NewRecorder()
 	TodoWithoutID(res, req)
 
 	return res, req
 }
 
+// SendRequest calls the handler with the request and records the response
+func SendRequest(req *http.Request, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	return res
+}
+
+// SendAuthRequest sends a request with the body as the current user
+func SendAuthRequest(method, url, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	return SendRequest(NewAuthRequest(method, url, bytes.NewReader([]byte(body))), handler)
+}
+
+// CreateTestTodo creates a todo from the JSON body as the current user,
+// failing the test if the todo is turned down
+func CreateTestTodo(t *testing.T, body string) Todo {
+	t.Helper()
+	res := SendAuthRequest("POST", "http://localhost:8080/todos", body, TodoWithoutID)
+	assertStatusCode(t, res.Result().StatusCode, http.StatusOK)
+	var todo Todo
+	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &todo))
+
+	return todo
+}
+
 func unmarshalAndAssert(t *testing.T, res *httptest.ResponseRecorder) map[string]interface{} {
 	var decodedResBody map[string]interface{}
 	assertRandomErr(t, json.Unmarshal(res.Body.Bytes(), &decodedResBody))
@@ -148,11 +192,15 @@ func unmarshalAndAssert(t *testing.T, res *httptest.ResponseRecorder) map[string
 	return decodedResBody
 }
 
+// NewAuthRequest creates a test request that carries the current session token
+func NewAuthRequest(method, url string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, url, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req
+}
+
+// LogIn switches the current session token to the one returned by GETUser
 func LogIn(user map[string]interface{}) {
-	// store into secret file
-	id := strconv.Itoa(int(user["id"].(float64)))
-	err := ioutil.WriteFile("/tmp/secret.txt", []byte(id), 0644)
-	if err != nil {
-		panic(err)
-	}
+	token = user["token"].(string)
 }
@@ -0,0 +1,87 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"time"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "agenda",
+		Short: "show open todos grouped by due date",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, err := Endpoint("/todos?completed=false&sort=due")
+			if err != nil {
+				return err
+			}
+			todos, err := FetchAllTodos(endpoint)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			groups := groupByDue(todos, now)
+			format, err := currentOutput()
+			if err != nil {
+				return err
+			}
+			// only tables are grouped, other formats get the todos of the
+			// agenda in order
+			if format != "table" {
+				agenda := []backend.Todo{}
+				for _, name := range agendaGroups {
+					agenda = append(agenda, groups[name]...)
+				}
+				return printTodos(agenda)
+			}
+
+			for _, name := range agendaGroups {
+				if len(groups[name]) == 0 {
+					continue
+				}
+				fmt.Println(name)
+				for _, todo := range groups[name] {
+					fmt.Printf("  [%d] %s (%s)\n", todo.ID, todo.Text, RelativeTime(*todo.Due, now))
+				}
+			}
+
+			return nil
+		},
+	}
+
+	rootCmd.AddCommand(cmd)
+}
+
+var agendaGroups = []string{"Overdue", "Today", "Tomorrow", "This week"}
+
+// groupByDue sorts the todos into the agenda groups, todos without a due
+// date or due after this week are left out
+func groupByDue(todos []backend.Todo, now time.Time) map[string][]backend.Todo {
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startOfTomorrow := startOfToday.AddDate(0, 0, 1)
+	endOfTomorrow := startOfToday.AddDate(0, 0, 2)
+	endOfWeek := startOfToday.AddDate(0, 0, 7)
+
+	groups := map[string][]backend.Todo{}
+	for _, todo := range todos {
+		if todo.Due == nil {
+			continue
+		}
+		due := todo.Due.In(now.Location())
+
+		switch {
+		case due.Before(now):
+			groups["Overdue"] = append(groups["Overdue"], todo)
+		case due.Before(startOfTomorrow):
+			groups["Today"] = append(groups["Today"], todo)
+		case due.Before(endOfTomorrow):
+			groups["Tomorrow"] = append(groups["Tomorrow"], todo)
+		case due.Before(endOfWeek):
+			groups["This week"] = append(groups["This week"], todo)
+		}
+	}
+
+	return groups
+}
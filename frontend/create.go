@@ -1,30 +1,89 @@
 package frontend
 
 import (
-	"fmt"
 	"github.com/spf13/cobra"
 	"log"
 	"net/http"
+	"time"
 )
 
 func init() {
-	var data string
+	var data, due, priority, repeat string
+	var tags []string
+	var project, parent int
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "create a todo",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reqBody := []byte(data)
+			if due != "" {
+				dueTime, err := ParseDue(due)
+				if err != nil {
+					return err
+				}
+				reqBody, err = SetJSONField(reqBody, "due", dueTime.Format(time.RFC3339))
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(tags) > 0 {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "tags", tags)
+				if err != nil {
+					return err
+				}
+			}
+
+			if project != 0 {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "project_id", project)
+				if err != nil {
+					return err
+				}
+			}
+
+			if parent != 0 {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "parent_id", parent)
+				if err != nil {
+					return err
+				}
+			}
+
+			if priority != "" {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "priority", priority)
+				if err != nil {
+					return err
+				}
+			}
+
+			if repeat != "" {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "repeat", ParseRepeat(repeat))
+				if err != nil {
+					return err
+				}
+			}
+
 			// POST the data to /todos
 			method := http.MethodPost
-			url := "http://localhost:8080/todos"
-			err := MakeRequest(method, url, []byte(data))
-
+			url, err := Endpoint("/todos")
 			if err != nil {
-				fmt.Println(err)
+				return err
 			}
+			return RequestTodo(method, url, reqBody)
 		},
 	}
 
 	cmd.Flags().StringVar(&data, "data", "", `todo create --data '{"text": "hello world"}'`)
+	cmd.Flags().StringVar(&due, "due", "", `due date, e.g. "2026-11-01" or "2026-11-01 17:00"`)
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "tag the todo, can be given multiple times")
+	cmd.Flags().IntVar(&project, "project", 0, "id of the project to add the todo to")
+	cmd.Flags().IntVar(&parent, "parent", 0, "id of the todo to add the todo below")
+	cmd.Flags().StringVar(&priority, "priority", "", "none, low, medium, high or 0 to 3")
+	cmd.Flags().StringVar(&repeat, "repeat", "", "repeat the todo once it is completed: "+repeatUsage)
 	err := cmd.MarkFlagRequired("data")
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,125 @@
+package frontend
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// credentialFlags are the flags of the commands asking for credentials
+type credentialFlags struct {
+	username      string
+	passwordStdin bool
+	data          string
+}
+
+func (f *credentialFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.username, "username", "", "name of the user, prompted for if missing")
+	cmd.Flags().BoolVar(&f.passwordStdin, "password-stdin", false, "read the password from stdin")
+	cmd.Flags().StringVar(&f.data, "data", "", `credentials as JSON, like {"uname":..,"pass":..}`)
+	if err := cmd.Flags().MarkDeprecated("data", "use --username and the password prompt or --password-stdin"); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// read returns the credentials as the JSON body the server expects, the
+// password is asked twice when confirm is set
+func (f *credentialFlags) read(confirm bool) ([]byte, error) {
+	if f.data != "" {
+		return []byte(f.data), nil
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	username := f.username
+	if username == "" {
+		if f.passwordStdin {
+			return nil, errors.New("--password-stdin needs --username")
+		}
+		fmt.Fprint(os.Stderr, "Username: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		username = strings.TrimSpace(line)
+	}
+	if username == "" {
+		return nil, errors.New("username must not be empty")
+	}
+
+	var password string
+	if f.passwordStdin {
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		var err error
+		if password, err = promptPassword("Password: "); err != nil {
+			return nil, err
+		}
+		if confirm {
+			again, err := promptPassword("Confirm password: ")
+			if err != nil {
+				return nil, err
+			}
+			if again != password {
+				return nil, errors.New("passwords do not match")
+			}
+		}
+	}
+	if password == "" {
+		return nil, errors.New("password must not be empty")
+	}
+
+	return json.Marshal(map[string]string{"uname": username, "pass": password})
+}
+
+// promptPassword reads a password from the terminal without echoing it
+func promptPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("stdin is not a terminal, use --password-stdin")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// logIn creates a session with the credentials and stores its token in the
+// active profile, the session is named after the host
+func logIn(credentials []byte) error {
+	var body map[string]interface{}
+	if err := json.Unmarshal(credentials, &body); err != nil {
+		return err
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		body["client"] = hostname
+	}
+	data, _ := json.Marshal(body)
+
+	var user map[string]interface{}
+	endpoint, err := Endpoint("/users")
+	if err != nil {
+		return err
+	}
+	if err := FetchJSON(http.MethodGet, endpoint, data, &user); err != nil {
+		return err
+	}
+	token, ok := user["token"].(string)
+	if !ok {
+		return errors.New("server did not return a session token")
+	}
+
+	return StoreToken(token)
+}
@@ -8,21 +8,30 @@ import (
 
 func init() {
 	var id string
+	var deleteChildren bool
 	cmd := &cobra.Command{
 		Use:   "delete",
-		Short: "delete a todo",
-		Run: func(cmd *cobra.Command, args []string) {
+		Short: "move a todo to the trash, its children move up to its parent unless --delete-children is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
 			method := http.MethodDelete
-			url := "http://localhost:8080/todos/" + id
-			err := MakeRequest(method, url, nil)
-
+			url, err := Endpoint("/todos/" + id)
 			if err != nil {
-				fmt.Println(err)
+				return err
+			}
+			if deleteChildren {
+				url += "?children=delete"
+			}
+			if err := MakeRequest(method, url, nil); err != nil {
+				return err
 			}
+			forgetVersion(id)
+			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&id, "id", "", "id of the todo to delete")
+	cmd.Flags().BoolVar(&deleteChildren, "delete-children", false, "delete every todo below the todo as well")
+	addForceFlag(cmd)
 	if err := cmd.MarkFlagRequired("id"); err != nil {
 		fmt.Println(err)
 		return
@@ -0,0 +1,75 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// layouts accepted by the --due flags, besides RFC 3339
+var dueLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDue parses a due date given on the command line in local time
+func ParseDue(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range dueLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New(`invalid due date, use "2006-01-02", "2006-01-02 15:04" or RFC 3339`)
+}
+
+// SetJSONField sets key to value in the JSON object data and returns the
+// encoded result, empty data is treated as an empty object
+func SetJSONField(data []byte, key string, value interface{}) ([]byte, error) {
+	decoded := map[string]interface{}{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+	}
+	decoded[key] = value
+
+	return json.Marshal(decoded)
+}
+
+// RelativeTime describes t relative to now, e.g. "in 3 hours" or "2 days ago"
+func RelativeTime(t, now time.Time) string {
+	d := t.Sub(now)
+	past := d < 0
+	if past {
+		d = -d
+	}
+
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		amount = plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		amount = plural(int(d/time.Hour), "hour")
+	default:
+		amount = plural(int(d/(24*time.Hour)), "day")
+	}
+
+	if past {
+		return amount + " ago"
+	}
+	return "in " + amount
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
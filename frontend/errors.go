@@ -0,0 +1,100 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"todo-cli/backend"
+)
+
+// Exit codes of the client, scripts can rely on them instead of matching
+// the error messages
+const (
+	ExitError        = 1
+	ExitInvalid      = 2
+	ExitUnauthorized = 3
+	ExitNotFound     = 4
+	ExitServer       = 5
+	ExitUnreachable  = 6
+)
+
+// friendlyMessages replace the messages of the server for some error codes,
+// the other codes keep the message of the server
+var friendlyMessages = map[string]string{
+	backend.CodeUnauthorized:       "not logged in or the session was revoked, run todo login",
+	backend.CodeInvalidCredentials: "wrong username or password",
+	backend.CodeUsernameTaken:      "that username is taken, pick another one",
+	backend.CodeInternal:           "the server ran into a problem, please try again later",
+	backend.CodeTodoNotFound:       "there is no todo with that id",
+	backend.CodeProjectNotFound:    "there is no project with that id",
+	backend.CodeTagNotFound:        "there is no tag with that id",
+	backend.CodeSessionNotFound:    "there is no session with that id",
+	backend.CodeStaleTodo:          "the todo was changed since you last saw it, check it with todo get --id and try again, or pass --force to overwrite it",
+}
+
+// RequestError is an error response of the server
+type RequestError struct {
+	Status int
+	backend.APIError
+}
+
+func (e *RequestError) Error() string {
+	message, ok := friendlyMessages[e.Code]
+	if !ok {
+		message = e.Message
+	}
+	if details, ok := e.Details.(map[string]interface{}); ok && e.Code == backend.CodeInvalidFilter {
+		message = fmt.Sprintf("%s at position %v", message, details["position"])
+	}
+
+	return message
+}
+
+// ExitCode returns the exit code for the error code of the server
+func (e *RequestError) ExitCode() int {
+	switch {
+	case e.Code == backend.CodeUnauthorized || e.Code == backend.CodeInvalidCredentials:
+		return ExitUnauthorized
+	case strings.HasSuffix(e.Code, "_not_found"):
+		return ExitNotFound
+	case strings.HasPrefix(e.Code, "invalid_"):
+		return ExitInvalid
+	case e.Status >= 500:
+		return ExitServer
+	}
+	return ExitError
+}
+
+// newRequestError decodes the error envelope of the response body, bodies
+// of older servers are kept as the message
+func newRequestError(status int, body []byte) error {
+	var resBody backend.ErrorResponse
+	if err := json.Unmarshal(body, &resBody); err != nil || resBody.Error == nil {
+		return &RequestError{Status: status, APIError: backend.APIError{Message: strings.TrimSpace(string(body))}}
+	}
+
+	return &RequestError{Status: status, APIError: *resBody.Error}
+}
+
+// usageError is an invalid flag of a command
+type usageError struct {
+	error
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	if errors.As(err, &usageError{}) {
+		return ExitInvalid
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.ExitCode()
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ExitUnreachable
+	}
+	return ExitError
+}
@@ -5,10 +5,17 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"todo-cli/backend"
 )
 
 func init() {
-	var id string
+	var id, sort, order, filter string
+	var limit int
+	var tags []string
+	var hideCompleted, onlyCompleted, all, tree bool
 	cmd := &cobra.Command{
 		Use:       "get",
 		Short:     "get a todo",
@@ -21,19 +28,83 @@ func init() {
 
 			return nil
 		},
-		Run: func(cmd *cobra.Command, args []string) {
-			method := http.MethodGet
-			url := "http://localhost:8080/todos"
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, err := Endpoint("/todos")
+			if err != nil {
+				return err
+			}
+			if id != "" && tree {
+				roots, err := fetchTree(id)
+				if err != nil {
+					return err
+				}
+				return printTree(roots)
+			}
 			if id != "" {
-				url += "/" + id
+				return RequestTodo(http.MethodGet, endpoint+"/"+id, nil)
 			}
 
-			err := MakeRequest(method, url, nil)
+			params := url.Values{}
+			if hideCompleted {
+				params.Set("completed", "false")
+			} else if onlyCompleted {
+				params.Set("completed", "true")
+			}
+			for _, tag := range tags {
+				params.Add("tag", tag)
+			}
+			if filter != "" {
+				params.Set("q", filter)
+			}
+			if limit != 0 {
+				params.Set("limit", strconv.Itoa(limit))
+			}
+			if sort != "" {
+				params.Set("sort", sort)
+			}
+			if order != "" {
+				params.Set("order", order)
+			}
+			if len(params) > 0 {
+				endpoint += "?" + params.Encode()
+			}
+
+			if tree {
+				todos, err := FetchAllTodos(endpoint)
+				if err != nil {
+					return err
+				}
+				return printTree(nestTodos(todos))
+			}
+			if all {
+				todos, err := FetchAllTodos(endpoint)
+				if err != nil {
+					return err
+				}
+				return printTodos(todos)
+			}
+
+			var todos []backend.Todo
+			next, err := fetchJSON(http.MethodGet, endpoint, nil, &todos)
 			if err != nil {
-				fmt.Println(err)
+				return err
+			}
+			if next != "" {
+				fmt.Fprintln(os.Stderr, "There are more todos, use --all to get every page")
 			}
+			return printTodos(todos)
 		},
 	}
 	cmd.Flags().StringVar(&id, "id", "", "get todo by id")
+	cmd.Flags().BoolVar(&hideCompleted, "hide-completed", false, "hide completed todos")
+	cmd.Flags().BoolVar(&onlyCompleted, "only-completed", false, "show only completed todos")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "only show todos with this tag, -tag hides them")
+	cmd.Flags().StringVar(&filter, "filter", "", `filter expression, e.g. 'status:open and (tag:work or project:infra) and due<2026-11-01'`)
+	cmd.Flags().IntVar(&limit, "limit", 0, "number of todos per page")
+	cmd.Flags().StringVar(&sort, "sort", "", "sort by created, due, text, priority or urgency")
+	cmd.Flags().StringVar(&order, "order", "", "sort order, asc or desc")
+	cmd.Flags().BoolVar(&all, "all", false, "fetch every page of todos")
+	cmd.Flags().BoolVar(&tree, "tree", false, "show todos below their parents with the progress of their children, implies --all")
+	cmd.MarkFlagsMutuallyExclusive("hide-completed", "only-completed")
 	rootCmd.AddCommand(cmd)
 }
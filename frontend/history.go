@@ -0,0 +1,167 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-cli/backend"
+)
+
+func init() {
+	var id string
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "list the changes of a todo, oldest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var revisions []backend.Revision
+			endpoint, err := Endpoint("/todos/" + id + "/history")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &revisions); err != nil {
+				return err
+			}
+			return printRevisions(revisions)
+		},
+	}
+
+	var to int
+	revertCmd := &cobra.Command{
+		Use:   "revert",
+		Short: "set a todo back to how it was right after a revision of todo history",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, _ := json.Marshal(map[string]int{"to": to})
+			endpoint, err := Endpoint("/todos/" + id + "/revert")
+			if err != nil {
+				return err
+			}
+			return RequestTodo(http.MethodPost, endpoint, data)
+		},
+	}
+	revertCmd.Flags().IntVar(&to, "to", 0, "id of the revision to go back to")
+	if err := revertCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, c := range []*cobra.Command{historyCmd, revertCmd} {
+		c.Flags().StringVar(&id, "id", "", "id of the todo")
+		if err := c.MarkFlagRequired("id"); err != nil {
+			fmt.Println(err)
+			return
+		}
+		rootCmd.AddCommand(c)
+	}
+}
+
+// printRevisions prints a change column in a table, with a word diff for
+// text changes, and the old and new values in the other formats
+func printRevisions(revisions []backend.Revision) error {
+	format, err := currentOutput()
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"REV", "CHANGED", "CLIENT", "FIELD", "OLD", "NEW"}
+	if format == "table" {
+		headers = []string{"REV", "CHANGED", "CLIENT", "FIELD", "CHANGE"}
+	}
+	rows := make([][]string, len(revisions))
+	for i, revision := range revisions {
+		oldValue, newValue := revisionValue(revision.OldValue), revisionValue(revision.NewValue)
+		rows[i] = []string{strconv.Itoa(revision.ID), revision.CreatedAt.Local().Format("2006-01-02 15:04"), revision.Client, revision.Field}
+		switch {
+		case format != "table":
+			rows[i] = append(rows[i], oldValue, newValue)
+		case revision.Field == "text":
+			rows[i] = append(rows[i], WordDiff(oldValue, newValue))
+		default:
+			rows[i] = append(rows[i], oldValue+" -> "+newValue)
+		}
+	}
+	return printOutput(revisions, headers, rows)
+}
+
+// revisionValue writes the JSON value of a revision for people, dates in
+// local time, lists separated by commas and none for null or empty lists
+func revisionValue(value backend.RevisionValue) string {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return string(value)
+	}
+	switch decoded := decoded.(type) {
+	case nil:
+		return "none"
+	case string:
+		if t, err := time.Parse(time.RFC3339, decoded); err == nil {
+			return t.Local().Format("2006-01-02 15:04")
+		}
+		return decoded
+	case []interface{}:
+		if len(decoded) == 0 {
+			return "none"
+		}
+		items := make([]string, len(decoded))
+		for i, item := range decoded {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(decoded)
+}
+
+// WordDiff marks the words removed from old with [-...-] and the words
+// added in new with {+...+}, like git diff --word-diff
+func WordDiff(old, new string) string {
+	a, b := strings.Fields(old), strings.Fields(new)
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var words, removed, added []string
+	flush := func() {
+		if len(removed) > 0 {
+			words = append(words, "[-"+strings.Join(removed, " ")+"-]")
+		}
+		if len(added) > 0 {
+			words = append(words, "{+"+strings.Join(added, " ")+"+}")
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			words = append(words, a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	flush()
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,37 @@
+package frontend
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+	"net/http"
+	"todo-cli/backend"
+)
+
+func init() {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "end the session of the active profile",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/sessions/current"
+			if all {
+				path = "/sessions"
+			}
+			url, err := Endpoint(path)
+			if err != nil {
+				return err
+			}
+			// forget the token even if the server already revoked it
+			err = MakeRequest(http.MethodDelete, url, nil)
+			var reqErr *RequestError
+			if err != nil && !(errors.As(err, &reqErr) && reqErr.Code == backend.CodeUnauthorized) {
+				return err
+			}
+
+			return StoreToken("")
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "end every session of the user on every device")
+
+	rootCmd.AddCommand(cmd)
+}
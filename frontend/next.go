@@ -0,0 +1,33 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "show the most urgent open todo",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var todos []backend.Todo
+			endpoint, err := Endpoint("/todos?completed=false&sort=urgency&order=desc&limit=1")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &todos); err != nil {
+				return err
+			}
+			if len(todos) == 0 {
+				fmt.Println("Nothing to do")
+				return nil
+			}
+
+			return printTodo(todos[0])
+		},
+	}
+
+	rootCmd.AddCommand(cmd)
+}
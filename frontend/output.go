@@ -0,0 +1,219 @@
+package frontend
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"todo-cli/backend"
+)
+
+// outputFormats are the values of --output
+var outputFormats = []string{"table", "json", "jsonl", "yaml", "csv"}
+
+// outputFormat and formatTemplate are set by --output and --format
+var outputFormat, formatTemplate string
+
+// currentOutput returns the output format picked by --format, --output or
+// the active profile, in that order, defaulting to a table
+func currentOutput() (string, error) {
+	if formatTemplate != "" {
+		return "template", nil
+	}
+	format := outputFormat
+	if format == "" {
+		if config, err := LoadClientConfig(); err == nil {
+			if _, profile, err := config.Active(); err == nil {
+				format = profile.Output
+			}
+		}
+	}
+	if format == "" {
+		return "table", nil
+	}
+	for _, f := range outputFormats {
+		if f == format {
+			return format, nil
+		}
+	}
+
+	return "", usageError{fmt.Errorf("unknown output format %q, use one of %s", format, strings.Join(outputFormats, ", "))}
+}
+
+// printOutput prints v, a slice or a single value, in the current output
+// format. The table and csv formats print the headers and rows.
+func printOutput(v interface{}, headers []string, rows [][]string) error {
+	format, err := currentOutput()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "template":
+		tmpl, err := template.New("format").Funcs(template.FuncMap{"join": strings.Join}).Parse(formatTemplate)
+		if err != nil {
+			return usageError{err}
+		}
+		for _, item := range outputItems(v) {
+			if err := tmpl.Execute(os.Stdout, item); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "jsonl":
+		for _, item := range outputItems(v) {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		}
+	case "yaml":
+		// go through JSON to keep the field names of the API
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		data, err = yaml.Marshal(decoded)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// outputItems returns the elements of a slice, or the value itself
+func outputItems(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+func printTodos(todos []backend.Todo) error {
+	return printOutput(todos, todoHeaders, todoRows(todos))
+}
+
+func printTodo(todo backend.Todo) error {
+	return printOutput(todo, todoHeaders, todoRows([]backend.Todo{todo}))
+}
+
+var todoHeaders = []string{"ID", "TEXT", "DONE", "DUE", "PRIORITY", "URGENCY", "TAGS", "PROJECT", "PARENT", "SERIES"}
+
+func todoRows(todos []backend.Todo) [][]string {
+	now := time.Now()
+	rows := make([][]string, len(todos))
+	for i, todo := range todos {
+		done, due, priority, project, parent, series := "", "", "", "", "", ""
+		if todo.Completed {
+			done = "x"
+		}
+		if todo.Due != nil {
+			due = todo.Due.Format(time.RFC3339)
+			if format, _ := currentOutput(); format == "table" {
+				due = fmt.Sprintf("%s (%s)", todo.Due.Local().Format("2006-01-02 15:04"), RelativeTime(*todo.Due, now))
+			}
+		}
+		if todo.Priority > backend.PriorityNone && todo.Priority < len(backend.PriorityNames) {
+			priority = backend.PriorityNames[todo.Priority]
+		}
+		if todo.ProjectID != nil {
+			project = strconv.Itoa(*todo.ProjectID)
+		}
+		if todo.ParentID != nil {
+			parent = strconv.Itoa(*todo.ParentID)
+		}
+		if todo.SeriesID != nil {
+			series = strconv.Itoa(*todo.SeriesID)
+		}
+		tags := make([]string, len(todo.Tags))
+		for j, tag := range todo.Tags {
+			tags[j] = tag.Name
+		}
+		urgency := strconv.FormatFloat(todo.Urgency, 'f', 2, 64)
+		rows[i] = []string{strconv.Itoa(todo.ID), todo.Text, done, due, priority, urgency, strings.Join(tags, ","), project, parent, series}
+	}
+	return rows
+}
+
+func printTagCounts(tags []backend.TagCount) error {
+	rows := make([][]string, len(tags))
+	for i, tag := range tags {
+		rows[i] = []string{strconv.Itoa(tag.ID), tag.Name, strconv.Itoa(tag.Count)}
+	}
+	return printOutput(tags, []string{"ID", "NAME", "TODOS"}, rows)
+}
+
+func printTag(tag backend.Tag) error {
+	return printOutput(tag, []string{"ID", "NAME"}, [][]string{{strconv.Itoa(tag.ID), tag.Name}})
+}
+
+func printSessions(sessions []backend.Session) error {
+	rows := make([][]string, len(sessions))
+	for i, session := range sessions {
+		current := ""
+		if session.Current {
+			current = "*"
+		}
+		rows[i] = []string{current, strconv.Itoa(session.ID), session.ClientName, session.UserAgent,
+			session.CreatedAt.Local().Format("2006-01-02 15:04"), session.LastUsedAt.Local().Format("2006-01-02 15:04")}
+	}
+	return printOutput(sessions, []string{"CURRENT", "ID", "CLIENT", "USER AGENT", "CREATED", "LAST USED"}, rows)
+}
+
+func printProjects(projects []backend.Project) error {
+	return printOutput(projects, projectHeaders, projectRows(projects))
+}
+
+func printProject(project backend.Project) error {
+	return printOutput(project, projectHeaders, projectRows([]backend.Project{project}))
+}
+
+var projectHeaders = []string{"ID", "NAME"}
+
+func projectRows(projects []backend.Project) [][]string {
+	rows := make([][]string, len(projects))
+	for i, project := range projects {
+		rows[i] = []string{strconv.Itoa(project.ID), project.Name}
+	}
+	return rows
+}
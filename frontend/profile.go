@@ -0,0 +1,314 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// profileName is the profile picked with --profile
+var profileName string
+
+// ClientConfig is the client config file with its named profiles
+type ClientConfig struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the server to talk to and the session token for it
+type Profile struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+	Output string `yaml:"output,omitempty"`
+}
+
+// clientConfigPath returns $XDG_CONFIG_HOME/todo/config.yaml, falling back
+// to ~/.config when XDG_CONFIG_HOME is not set
+func clientConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "todo", "config.yaml"), nil
+}
+
+// LoadClientConfig reads the client config, without a config file there is
+// a default profile which picks up the token of older versions
+func LoadClientConfig() (*ClientConfig, error) {
+	path, err := clientConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		profile := &Profile{Server: defaultServer}
+		if home, err := os.UserHomeDir(); err == nil {
+			if token, err := ioutil.ReadFile(filepath.Join(home, ".todo_token")); err == nil {
+				profile.Token = strings.TrimSpace(string(token))
+			}
+		}
+		return &ClientConfig{Current: "default", Profiles: map[string]*Profile{"default": profile}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var config ClientConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+	return &config, nil
+}
+
+// Save writes the client config, it is only readable by the user since it
+// holds the session tokens
+func (c *ClientConfig) Save() error {
+	path, err := clientConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Active returns the name and profile picked by --profile, TODO_PROFILE or
+// the current profile of the config, in that order
+func (c *ClientConfig) Active() (string, *Profile, error) {
+	name := profileName
+	if name == "" {
+		name = os.Getenv("TODO_PROFILE")
+	}
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return name, nil, fmt.Errorf("unknown profile %q, add it with todo profile add", name)
+	}
+	return name, profile, nil
+}
+
+// Endpoint returns the url of the path on the server of the active
+// profile, TODO_SERVER overrides the server
+func Endpoint(path string) (string, error) {
+	server := os.Getenv("TODO_SERVER")
+	if server == "" {
+		config, err := LoadClientConfig()
+		if err != nil {
+			return "", err
+		}
+		_, profile, err := config.Active()
+		if err != nil {
+			return "", err
+		}
+		server = profile.Server
+	}
+
+	return strings.TrimSuffix(server, "/") + path, nil
+}
+
+// onProfileServer reports whether the requests go to the server of the
+// profile, the token of the profile is only sent there and not to another
+// server named by TODO_SERVER
+func onProfileServer(profile *Profile) bool {
+	server := os.Getenv("TODO_SERVER")
+	return server == "" || strings.TrimSuffix(server, "/") == strings.TrimSuffix(profile.Server, "/")
+}
+
+// StoreToken saves the session token in the active profile
+func StoreToken(token string) error {
+	config, err := LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	name, profile, err := config.Active()
+	if err != nil {
+		return err
+	}
+	if !onProfileServer(profile) {
+		// the token of the profile belongs to another server
+		if token == "" {
+			return nil
+		}
+		return fmt.Errorf("TODO_SERVER is not the server of profile %q, add a profile for it with todo profile add", name)
+	}
+	profile.Token = token
+
+	return config.Save()
+}
+
+// ReadToken returns the session token of the active profile, TODO_TOKEN
+// overrides it. The token of the profile is left out when TODO_SERVER
+// points to another server.
+func ReadToken() (string, error) {
+	if token := os.Getenv("TODO_TOKEN"); token != "" {
+		return token, nil
+	}
+	config, err := LoadClientConfig()
+	if err != nil {
+		return "", err
+	}
+	name, profile, err := config.Active()
+	if err != nil {
+		return "", err
+	}
+	if !onProfileServer(profile) {
+		return "", fmt.Errorf("TODO_SERVER is not the server of profile %q, set TODO_TOKEN to log in to it", name)
+	}
+	if profile.Token == "" {
+		return "", fmt.Errorf("not logged in with profile %q", name)
+	}
+
+	return profile.Token, nil
+}
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "manage the servers and credentials of the client",
+	}
+
+	var server, output string
+	var use bool
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "add a profile or change its server",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadClientConfig()
+			if err != nil {
+				return err
+			}
+
+			profile, ok := config.Profiles[args[0]]
+			if !ok {
+				profile = &Profile{Server: server}
+				config.Profiles[args[0]] = profile
+			} else if cmd.Flags().Changed("server") && profile.Server != server {
+				// the token belongs to the old server
+				profile.Server, profile.Token = server, ""
+			}
+			if cmd.Flags().Changed("output") {
+				profile.Output = output
+			}
+			if use {
+				config.Current = args[0]
+			}
+
+			return config.Save()
+		},
+	}
+	addCmd.Flags().StringVar(&server, "server", defaultServer, "url of the server, an existing profile keeps its server unless given")
+	addCmd.Flags().StringVar(&output, "output", "", "default output format of the profile")
+	addCmd.Flags().BoolVar(&use, "use", false, "make it the current profile")
+
+	useCmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "switch to a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			if _, ok := config.Profiles[args[0]]; !ok {
+				return fmt.Errorf("unknown profile %q", args[0])
+			}
+			config.Current = args[0]
+
+			return config.Save()
+		},
+	}
+
+	lsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "list the profiles, the active one is marked with *",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			active, _, _ := config.Active()
+
+			names := make([]string, 0, len(config.Profiles))
+			for name := range config.Profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				profile := config.Profiles[name]
+				marker, status := " ", "logged out"
+				if name == active {
+					marker = "*"
+				}
+				if profile.Token != "" {
+					status = "logged in"
+				}
+				fmt.Printf("%s %s\t%s\t%s\n", marker, name, profile.Server, status)
+			}
+			return nil
+		},
+	}
+
+	rmCmd := &cobra.Command{
+		Use:   "rm <name>",
+		Short: "remove a profile along with its token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadClientConfig()
+			if err != nil {
+				return err
+			}
+			if _, ok := config.Profiles[args[0]]; !ok {
+				return fmt.Errorf("unknown profile %q", args[0])
+			}
+			delete(config.Profiles, args[0])
+			if config.Current == args[0] {
+				// switch to the first remaining profile, without one the
+				// commands ask to add a profile
+				names := make([]string, 0, len(config.Profiles))
+				for name := range config.Profiles {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				config.Current = ""
+				if len(names) > 0 {
+					config.Current = names[0]
+					fmt.Printf("switched to profile %s\n", names[0])
+				}
+			}
+
+			return config.Save()
+		},
+	}
+
+	cmd.AddCommand(addCmd, useCmd, lsCmd, rmCmd)
+	rootCmd.AddCommand(cmd)
+}
@@ -0,0 +1,141 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"net/url"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "manage projects that group todos",
+	}
+
+	var name string
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "add a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, _ := json.Marshal(map[string]string{"name": name})
+			var project backend.Project
+			endpoint, err := Endpoint("/projects")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodPost, endpoint, data, &project); err != nil {
+				return err
+			}
+			return printProject(project)
+		},
+	}
+	addCmd.Flags().StringVar(&name, "name", "", "name of the project")
+	if err := addCmd.MarkFlagRequired("name"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	lsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "list projects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var projects []backend.Project
+			endpoint, err := Endpoint("/projects")
+			if err != nil {
+				return err
+			}
+			err = FetchJSON(http.MethodGet, endpoint, nil, &projects)
+			if err != nil {
+				return err
+			}
+
+			return printProjects(projects)
+		},
+	}
+
+	var todosID string
+	todosCmd := &cobra.Command{
+		Use:   "todos",
+		Short: "list the todos of a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, err := Endpoint("/projects/" + todosID + "/todos")
+			if err != nil {
+				return err
+			}
+			var todos []backend.Todo
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &todos); err != nil {
+				return err
+			}
+			return printTodos(todos)
+		},
+	}
+	todosCmd.Flags().StringVar(&todosID, "id", "", "id of the project")
+	if err := todosCmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var renameID, newName string
+	renameCmd := &cobra.Command{
+		Use:   "rename",
+		Short: "rename a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, _ := json.Marshal(map[string]string{"name": newName})
+			var project backend.Project
+			endpoint, err := Endpoint("/projects/" + renameID)
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodPut, endpoint, data, &project); err != nil {
+				return err
+			}
+			return printProject(project)
+		},
+	}
+	renameCmd.Flags().StringVar(&renameID, "id", "", "id of the project to rename")
+	renameCmd.Flags().StringVar(&newName, "name", "", "new name of the project")
+	if err := renameCmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	} else if err := renameCmd.MarkFlagRequired("name"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var rmID, moveTo string
+	var deleteTodos bool
+	rmCmd := &cobra.Command{
+		Use:   "rm",
+		Short: "remove a project, its todos are kept without a project unless --move-to or --delete-todos is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params := url.Values{}
+			if deleteTodos {
+				params.Set("todos", "delete")
+			} else if moveTo != "" {
+				params.Set("move_to", moveTo)
+			}
+			endpoint, err := Endpoint("/projects/" + rmID)
+			if err != nil {
+				return err
+			}
+			if len(params) > 0 {
+				endpoint += "?" + params.Encode()
+			}
+			return MakeRequest(http.MethodDelete, endpoint, nil)
+		},
+	}
+	rmCmd.Flags().StringVar(&rmID, "id", "", "id of the project to remove")
+	rmCmd.Flags().StringVar(&moveTo, "move-to", "", "id of the project to move the todos to")
+	rmCmd.Flags().BoolVar(&deleteTodos, "delete-todos", false, "delete the todos of the project as well")
+	rmCmd.MarkFlagsMutuallyExclusive("move-to", "delete-todos")
+	if err := rmCmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cmd.AddCommand(addCmd, lsCmd, todosCmd, renameCmd, rmCmd)
+	rootCmd.AddCommand(cmd)
+}
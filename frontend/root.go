@@ -9,8 +9,10 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"runtime"
+	"strings"
 	"todo-cli/backend"
 )
 
@@ -24,8 +26,12 @@ var rootCmd = &cobra.Command{
 var startServerCmd = &cobra.Command{
 	Use:   "start server",
 	Short: "start the backend server",
-	Run: func(cmd *cobra.Command, args []string) {
-		backend.StartServer()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := backend.LoadConfig(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		return backend.StartServer(cfg)
 	},
 }
 
@@ -49,17 +55,33 @@ var cmd = &cobra.Command{
 }
 
 func Execute() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "client profile to use, defaults to the current one")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "output format, one of "+strings.Join(outputFormats, ", "))
+	rootCmd.PersistentFlags().StringVar(&formatTemplate, "format", "", `Go template printed for every item, e.g. '{{.ID}} {{.Text}}'`)
+	backend.AddConfigFlags(startServerCmd.Flags())
 	rootCmd.AddCommand(startServerCmd)
 	rootCmd.AddCommand(cmd)
 
+	// errors are printed with their exit code below, the usage is only
+	// shown for bad flags
+	rootCmd.SilenceErrors = true
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	}
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
 	if err := rootCmd.Execute(); err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
 
+// MakeRequest sends the request and prints the text response, like the
+// message of a deletion
 func MakeRequest(method, url string, data []byte) error {
-	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	req, err := newRequest(method, url, data)
 	if err != nil {
 		return err
 	}
@@ -75,26 +97,110 @@ func MakeRequest(method, url string, data []byte) error {
 	if err != nil {
 		return err
 	}
+	if res.StatusCode >= 400 {
+		return newRequestError(res.StatusCode, resBody)
+	}
 
-	// decode
-	var decodedResBody interface{}
-	if err := json.Unmarshal(resBody, &decodedResBody); err != nil {
-		fmt.Print(string(resBody))
-		return nil
+	fmt.Println(strings.TrimSpace(string(resBody)))
+	return nil
+}
+
+// RequestTodo sends the request and prints the todo of the response, its
+// version is remembered for the next change
+func RequestTodo(method, url string, data []byte) error {
+	var todo backend.Todo
+	if err := FetchJSON(method, url, data, &todo); err != nil {
+		return err
 	}
+	rememberVersion(todo)
+
+	return printTodo(todo)
+}
+
+// FetchJSON makes the request and decodes the JSON response into v,
+// a response with an error status is returned as an error
+func FetchJSON(method, url string, data []byte, v interface{}) error {
+	_, err := fetchJSON(method, url, data, v)
+	return err
+}
 
-	// if the response is an array, loop over
-	// otherwise print directly
-	sliceResBody, ok := decodedResBody.([]interface{})
-	if ok {
-		for _, v := range sliceResBody {
-			fmt.Println(v)
+// FetchAllTodos gets the todos from the url, following every page
+func FetchAllTodos(url string) ([]backend.Todo, error) {
+	var todos []backend.Todo
+	for {
+		var page []backend.Todo
+		next, err := fetchJSON(http.MethodGet, url, nil, &page)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		fmt.Println(decodedResBody)
+		todos = append(todos, page...)
+		if next == "" {
+			return todos, nil
+		}
+		if url, err = withCursor(url, next); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func fetchJSON(method, url string, data []byte, v interface{}) (string, error) {
+	req, err := newRequest(method, url, data)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	client := http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode >= 400 {
+		return "", newRequestError(res.StatusCode, resBody)
+	}
+
+	return res.Header.Get("X-Next-Cursor"), json.Unmarshal(resBody, v)
+}
+
+// withCursor sets the after query param of the url to the cursor
+func withCursor(rawURL, cursor string) (string, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	params := u.Query()
+	params.Set("after", cursor)
+	u.RawQuery = params.Encode()
+
+	return u.String(), nil
+}
+
+// newRequest creates a request that carries the session token if logged in
+func newRequest(method, url string, data []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "todo-cli")
+	if method == http.MethodPatch {
+		// a JSON Patch is a list of operations, anything else a merge patch
+		contentType := backend.MergePatchType
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			contentType = backend.JSONPatchType
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	setIfMatch(req)
+	if token, err := ReadToken(); err == nil {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req, nil
 }
 
 func HandleError(err error) (b bool) {
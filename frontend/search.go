@@ -0,0 +1,52 @@
+package frontend
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "search [words]",
+		Short: "search todos by their text",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing search words")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var results []backend.SearchResult
+			endpoint, err := Endpoint("/todos/search?q=" + url.QueryEscape(strings.Join(args, " ")))
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &results); err != nil {
+				return err
+			}
+
+			format, err := currentOutput()
+			if err != nil {
+				return err
+			}
+			// print the matches in bold in tables, other formats get the
+			// plain snippet
+			highlight := strings.NewReplacer("<b>", "", "</b>", "")
+			if format == "table" {
+				highlight = strings.NewReplacer("<b>", "\033[1m", "</b>", "\033[0m")
+			}
+			rows := make([][]string, len(results))
+			for i, result := range results {
+				rows[i] = []string{strconv.Itoa(result.ID), highlight.Replace(result.Snippet)}
+			}
+			return printOutput(results, []string{"ID", "MATCH"}, rows)
+		},
+	}
+
+	rootCmd.AddCommand(cmd)
+}
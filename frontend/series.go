@@ -0,0 +1,94 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"strings"
+	"todo-cli/backend"
+)
+
+// repeatShortcuts are the names --repeat takes besides an RRULE
+var repeatShortcuts = map[string]string{
+	"daily":    "FREQ=DAILY",
+	"weekdays": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"weekly":   "FREQ=WEEKLY",
+	"biweekly": "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":  "FREQ=MONTHLY",
+	"yearly":   "FREQ=YEARLY",
+}
+
+const repeatUsage = `daily, weekdays, weekly, biweekly, monthly, yearly or an RRULE like
+"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "FREQ=MONTHLY;BYMONTHDAY=-1" or
+"FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION" for 3 days after completing the todo`
+
+// ParseRepeat turns a shortcut of --repeat into its rule, rules are
+// checked by the server
+func ParseRepeat(value string) string {
+	if rule, ok := repeatShortcuts[strings.ToLower(value)]; ok {
+		return rule
+	}
+	return value
+}
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "series",
+		Short: "show, change or stop the repetition of a todo",
+	}
+
+	var id, repeat string
+	sendSeries := func(method string, data []byte) error {
+		var todos []backend.Todo
+		endpoint, err := Endpoint("/series/" + id)
+		if err != nil {
+			return err
+		}
+		if err := FetchJSON(method, endpoint, data, &todos); err != nil {
+			return err
+		}
+		return printTodos(todos)
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "list the todos of a series",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendSeries(http.MethodGet, nil)
+		},
+	}
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "change how the open todos of a series repeat",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, _ := json.Marshal(map[string]string{"repeat": ParseRepeat(repeat)})
+			return sendSeries(http.MethodPut, data)
+		},
+	}
+	editCmd.Flags().StringVar(&repeat, "repeat", "", repeatUsage)
+	if err := editCmd.MarkFlagRequired("repeat"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	stopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "stop a series, its open todos are kept but not repeated",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendSeries(http.MethodPut, []byte(`{"repeat": null}`))
+		},
+	}
+
+	for _, c := range []*cobra.Command{showCmd, editCmd, stopCmd} {
+		c.Flags().StringVar(&id, "id", "", "id of the series, the id of its first todo")
+		if err := c.MarkFlagRequired("id"); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	cmd.AddCommand(showCmd, editCmd, stopCmd)
+	rootCmd.AddCommand(cmd)
+}
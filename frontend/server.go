@@ -0,0 +1,98 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "maintain the backend server, `todo start` runs it",
+		// server only groups the maintenance commands
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	// the subcommands open the store of the server config
+	backend.AddConfigFlags(cmd.PersistentFlags())
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "manage the database schema",
+	}
+
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "apply every pending migration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openStore(cmd)
+			if err != nil {
+				return err
+			}
+			if err := s.MigrateUp(); err != nil {
+				return err
+			}
+			return printMigrationStatus(s)
+		},
+	}
+
+	var steps int
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "revert the latest applied migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openStore(cmd)
+			if err != nil {
+				return err
+			}
+			if err := s.MigrateDown(steps); err != nil {
+				return err
+			}
+			return printMigrationStatus(s)
+		},
+	}
+	downCmd.Flags().IntVar(&steps, "steps", 1, "number of migrations to revert")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "list the migrations and whether they are applied",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openStore(cmd)
+			if err != nil {
+				return err
+			}
+			return printMigrationStatus(s)
+		},
+	}
+
+	migrateCmd.AddCommand(upCmd, downCmd, statusCmd)
+	cmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(cmd)
+}
+
+// openStore opens the store of the server config given to the command
+func openStore(cmd *cobra.Command) (backend.Store, error) {
+	cfg, err := backend.LoadConfig(cmd.Flags())
+	if err != nil {
+		return nil, err
+	}
+	return backend.InitStore(cfg)
+}
+
+func printMigrationStatus(s backend.Store) error {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range status {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = "applied " + m.AppliedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%04d_%s  %s\n", m.Version, m.Name, applied)
+	}
+	return nil
+}
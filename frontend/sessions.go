@@ -0,0 +1,55 @@
+package frontend
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+	"net/http"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "list the sessions of the user, the current one is marked with *",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sessions []backend.Session
+			endpoint, err := Endpoint("/sessions")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &sessions); err != nil {
+				return err
+			}
+
+			return printSessions(sessions)
+		},
+	}
+
+	var others bool
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "revoke a session, like the one of a lost device",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if others {
+				endpoint, err := Endpoint("/sessions?keep_current=true")
+				if err != nil {
+					return err
+				}
+				return MakeRequest(http.MethodDelete, endpoint, nil)
+			}
+			if len(args) == 0 {
+				return errors.New("give the id of the session or --others")
+			}
+			endpoint, err := Endpoint("/sessions/" + args[0])
+			if err != nil {
+				return err
+			}
+			return MakeRequest(http.MethodDelete, endpoint, nil)
+		},
+	}
+	revokeCmd.Flags().BoolVar(&others, "others", false, "revoke every session except the current one")
+
+	cmd.AddCommand(revokeCmd)
+	rootCmd.AddCommand(cmd)
+}
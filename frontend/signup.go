@@ -1,33 +1,40 @@
 package frontend
 
 import (
+	"fmt"
 	"github.com/spf13/cobra"
-	"log"
+	"net/http"
 )
 
 func init() {
-	data := ""
+	var flags credentialFlags
 	cmd := &cobra.Command{
 		Use:   "signup",
 		Short: "signup for a user",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := MakeRequest(
-				"POST",
-				"http://localhost:8080/users",
-				[]byte(data),
-			)
+			credentials, err := flags.read(true)
 			if err != nil {
 				return err
 			}
+			var user map[string]interface{}
+			endpoint, err := Endpoint("/users")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodPost, endpoint, credentials, &user); err != nil {
+				return err
+			}
 
+			// log in right away so the new user can start adding todos
+			if err := logIn(credentials); err != nil {
+				return err
+			}
+
+			fmt.Printf("Successfully signed up as %v\n", user["uname"])
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&data, "data", "", "provide data for creating user")
-	err := cmd.MarkFlagRequired("data")
-	if err != nil {
-		log.Fatal(err)
-	}
+	flags.register(cmd)
 	rootCmd.AddCommand(cmd)
 }
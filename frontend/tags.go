@@ -0,0 +1,58 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "list tags with the number of todos using them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var tags []backend.TagCount
+			url, err := Endpoint("/tags")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, url, nil, &tags); err != nil {
+				return err
+			}
+
+			return printTagCounts(tags)
+		},
+	}
+
+	var id, name string
+	renameCmd := &cobra.Command{
+		Use:   "rename",
+		Short: "rename a tag, renaming to an existing tag merges them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, _ := json.Marshal(map[string]string{"name": name})
+			url, err := Endpoint("/tags/" + id)
+			if err != nil {
+				return err
+			}
+			var tag backend.Tag
+			if err := FetchJSON(http.MethodPut, url, data, &tag); err != nil {
+				return err
+			}
+			return printTag(tag)
+		},
+	}
+	renameCmd.Flags().StringVar(&id, "id", "", "id of the tag to rename")
+	renameCmd.Flags().StringVar(&name, "name", "", "new name of the tag")
+	if err := renameCmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	} else if err := renameCmd.MarkFlagRequired("name"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(cmd)
+}
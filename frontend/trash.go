@@ -0,0 +1,64 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"todo-cli/backend"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "list, restore or empty the deleted todos",
+	}
+
+	lsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "list the deleted todos, most recently deleted first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var todos []backend.Todo
+			endpoint, err := Endpoint("/trash")
+			if err != nil {
+				return err
+			}
+			if err := FetchJSON(http.MethodGet, endpoint, nil, &todos); err != nil {
+				return err
+			}
+			return printTodos(todos)
+		},
+	}
+
+	var id string
+	restoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "take a todo out of the trash along with the todos deleted with it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, err := Endpoint("/todos/" + id + "/restore")
+			if err != nil {
+				return err
+			}
+			return RequestTodo(http.MethodPost, endpoint, nil)
+		},
+	}
+	restoreCmd.Flags().StringVar(&id, "id", "", "id of the todo to restore")
+	if err := restoreCmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	emptyCmd := &cobra.Command{
+		Use:   "empty",
+		Short: "delete every todo in the trash for good",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, err := Endpoint("/trash")
+			if err != nil {
+				return err
+			}
+			return MakeRequest(http.MethodDelete, endpoint, nil)
+		},
+	}
+
+	cmd.AddCommand(lsCmd, restoreCmd, emptyCmd)
+	rootCmd.AddCommand(cmd)
+}
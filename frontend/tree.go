@@ -0,0 +1,118 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"todo-cli/backend"
+)
+
+// fetchTree fetches the todo with the id and nests every todo below it
+func fetchTree(id string) ([]backend.Todo, error) {
+	var todo backend.Todo
+	endpoint, err := Endpoint("/todos/" + id)
+	if err != nil {
+		return nil, err
+	}
+	if err := FetchJSON(http.MethodGet, endpoint, nil, &todo); err != nil {
+		return nil, err
+	}
+	endpoint, err = Endpoint("/todos/" + id + "/children?subtree=true")
+	if err != nil {
+		return nil, err
+	}
+	if err := FetchJSON(http.MethodGet, endpoint, nil, &todo.Children); err != nil {
+		return nil, err
+	}
+	return []backend.Todo{todo}, nil
+}
+
+// nestTodos nests the todos under their parents, the todos whose parent is
+// not among them are the roots. The progress counts the given todos only.
+func nestTodos(todos []backend.Todo) []backend.Todo {
+	ids := map[int]bool{}
+	for _, todo := range todos {
+		ids[todo.ID] = true
+	}
+	var roots []backend.Todo
+	byParent := map[int][]backend.Todo{}
+	for _, todo := range todos {
+		if todo.ParentID != nil && ids[*todo.ParentID] {
+			byParent[*todo.ParentID] = append(byParent[*todo.ParentID], todo)
+		} else {
+			roots = append(roots, todo)
+		}
+	}
+
+	var nest func(level []backend.Todo) []backend.Todo
+	nest = func(level []backend.Todo) []backend.Todo {
+		for i := range level {
+			level[i].Children = nest(byParent[level[i].ID])
+			level[i].Progress = nil
+			for _, child := range level[i].Children {
+				if level[i].Progress == nil {
+					level[i].Progress = &backend.Progress{}
+				}
+				level[i].Progress.Total++
+				if child.Completed {
+					level[i].Progress.Done++
+				}
+				if child.Progress != nil {
+					level[i].Progress.Total += child.Progress.Total
+					level[i].Progress.Done += child.Progress.Done
+				}
+			}
+		}
+		return level
+	}
+	return nest(roots)
+}
+
+// printTree draws the todos as a tree in tables, the other formats get the
+// nested todos and csv every todo in the order of the tree
+func printTree(roots []backend.Todo) error {
+	format, err := currentOutput()
+	if err != nil {
+		return err
+	}
+	if format != "table" {
+		return printOutput(roots, todoHeaders, todoRows(flattenTree(roots)))
+	}
+
+	var draw func(todos []backend.Todo, indent string)
+	draw = func(todos []backend.Todo, indent string) {
+		for i, todo := range todos {
+			branch, next := "├── ", "│   "
+			if i == len(todos)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Println(indent + branch + treeLine(todo))
+			draw(todo.Children, indent+next)
+		}
+	}
+	for _, root := range roots {
+		fmt.Println(treeLine(root))
+		draw(root.Children, "")
+	}
+	return nil
+}
+
+func treeLine(todo backend.Todo) string {
+	line := []string{fmt.Sprintf("[%d] %s", todo.ID, todo.Text)}
+	if todo.Completed {
+		line = append(line, "(done)")
+	}
+	if todo.Progress != nil {
+		line = append(line, fmt.Sprintf("%d/%d", todo.Progress.Done, todo.Progress.Total))
+	}
+	return strings.Join(line, " ")
+}
+
+func flattenTree(todos []backend.Todo) []backend.Todo {
+	flat := []backend.Todo{}
+	for _, todo := range todos {
+		flat = append(flat, todo)
+		flat = append(flat, flattenTree(todo.Children)...)
+	}
+	return flat
+}
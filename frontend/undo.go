@@ -0,0 +1,47 @@
+package frontend
+
+import (
+	"github.com/spf13/cobra"
+	"net/http"
+	"strconv"
+	"todo-cli/backend"
+)
+
+func init() {
+	for _, c := range []struct{ use, short, path string }{
+		{"undo", "reverse your most recent create, update, complete or delete", "/undo"},
+		{"redo", "apply the most recently undone operation again", "/redo"},
+	} {
+		path := c.path
+		rootCmd.AddCommand(&cobra.Command{
+			Use:   c.use,
+			Short: c.short,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var op backend.Operation
+				endpoint, err := Endpoint(path)
+				if err != nil {
+					return err
+				}
+				if err := FetchJSON(http.MethodPost, endpoint, nil, &op); err != nil {
+					return err
+				}
+				if op.Todo != nil {
+					rememberVersion(*op.Todo)
+				}
+				return printOperation(op)
+			},
+		})
+	}
+}
+
+func printOperation(op backend.Operation) error {
+	state, text := "redone", ""
+	if op.Undone {
+		state = "undone"
+	}
+	if op.Todo != nil {
+		text = op.Todo.Text
+	}
+	row := []string{strconv.Itoa(op.ID), op.Kind, state, strconv.Itoa(op.TodoID), text}
+	return printOutput(op, []string{"OPERATION", "KIND", "STATE", "TODO", "TEXT"}, [][]string{row})
+}
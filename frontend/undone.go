@@ -0,0 +1,32 @@
+package frontend
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+)
+
+func init() {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "undone",
+		Short: "mark a todo as not completed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method := http.MethodPut
+			url, err := Endpoint("/todos/" + id)
+			if err != nil {
+				return err
+			}
+			return RequestTodo(method, url, []byte(`{"completed": false}`))
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "id of the todo to mark as not completed")
+	addForceFlag(cmd)
+	if err := cmd.MarkFlagRequired("id"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	rootCmd.AddCommand(cmd)
+}
@@ -1,36 +1,141 @@
 package frontend
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func init() {
-	var id, data string
+	var id, data, jsonPatch, due, project, parent, priority, repeat string
+	var tags []string
 	cmd := &cobra.Command{
 		Use:   "update",
-		Short: "Update a todo with id and data",
-		Run: func(cmd *cobra.Command, args []string) {
-			method := http.MethodPut
-			url := "http://localhost:8080/todos/" + id
-			err := MakeRequest(method, url, []byte(data))
-
+		Short: "Update a todo with id and data, only the given fields are sent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url, err := Endpoint("/todos/" + id)
 			if err != nil {
-				fmt.Println(err)
+				return err
+			}
+			if jsonPatch != "" {
+				for _, name := range []string{"data", "due", "tag", "project", "parent", "priority", "repeat"} {
+					if cmd.Flags().Changed(name) {
+						return usageError{fmt.Errorf("--json-patch can't be combined with --%s", name)}
+					}
+				}
+				return RequestTodo(http.MethodPatch, url, []byte(jsonPatch))
 			}
+
+			reqBody := []byte(data)
+			if due != "" {
+				// "none" removes the due date
+				var dueValue interface{}
+				if due != "none" {
+					dueTime, err := ParseDue(due)
+					if err != nil {
+						return err
+					}
+					dueValue = dueTime.Format(time.RFC3339)
+				}
+				var err error
+				reqBody, err = SetJSONField(reqBody, "due", dueValue)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cmd.Flags().Changed("tag") {
+				// replace the tags, --tag "" removes all of them
+				names := []string{}
+				for _, tag := range tags {
+					if tag != "" {
+						names = append(names, tag)
+					}
+				}
+				var err error
+				reqBody, err = SetJSONField(reqBody, "tags", names)
+				if err != nil {
+					return err
+				}
+			}
+
+			if project != "" {
+				// "none" takes the todo out of its project
+				var projectID interface{}
+				if project != "none" {
+					id, err := strconv.Atoi(project)
+					if err != nil {
+						return errors.New("invalid project id")
+					}
+					projectID = id
+				}
+				var err error
+				reqBody, err = SetJSONField(reqBody, "project_id", projectID)
+				if err != nil {
+					return err
+				}
+			}
+
+			if parent != "" {
+				// "none" makes it a top level todo
+				var parentID interface{}
+				if parent != "none" {
+					id, err := strconv.Atoi(parent)
+					if err != nil {
+						return errors.New("invalid parent id")
+					}
+					parentID = id
+				}
+				var err error
+				reqBody, err = SetJSONField(reqBody, "parent_id", parentID)
+				if err != nil {
+					return err
+				}
+			}
+
+			if priority != "" {
+				var err error
+				reqBody, err = SetJSONField(reqBody, "priority", priority)
+				if err != nil {
+					return err
+				}
+			}
+
+			if repeat != "" {
+				// "none" stops the repetition
+				var rule interface{}
+				if repeat != "none" {
+					rule = ParseRepeat(repeat)
+				}
+				var err error
+				reqBody, err = SetJSONField(reqBody, "repeat", rule)
+				if err != nil {
+					return err
+				}
+			}
+
+			return RequestTodo(http.MethodPatch, url, reqBody)
 		},
 	}
 
 	cmd.Flags().StringVar(&id, "id", "", "specify the id of the todo")
-	cmd.Flags().StringVar(&data, "data", "", "specify the todo data to update")
+	cmd.Flags().StringVar(&data, "data", "", `specify the todo data to update as a merge patch, null removes a field`)
+	cmd.Flags().StringVar(&jsonPatch, "json-patch", "", `JSON Patch operations to apply, e.g. '[{"op": "add", "path": "/tags/-", "value": "work"}]'`)
+	cmd.Flags().StringVar(&due, "due", "", `specify the due date, "none" removes it`)
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, `replace the tags of the todo, --tag "" removes them`)
+	cmd.Flags().StringVar(&project, "project", "", `move the todo to a project, "none" takes it out`)
+	cmd.Flags().StringVar(&parent, "parent", "", `move the todo below another todo, "none" makes it a top level todo`)
+	cmd.Flags().StringVar(&priority, "priority", "", "none, low, medium, high or 0 to 3")
+	cmd.Flags().StringVar(&repeat, "repeat", "", `repeat the todo once it is completed, "none" stops it`)
+	addForceFlag(cmd)
 	if err := cmd.MarkFlagRequired("id"); err != nil {
 		fmt.Println(err)
 		return
-	} else if err := cmd.MarkFlagRequired("data"); err != nil {
-		fmt.Println(err)
-		return
 	}
+	cmd.MarkFlagsOneRequired("data", "json-patch", "due", "tag", "project", "parent", "priority", "repeat")
 
 	rootCmd.AddCommand(cmd)
 }
@@ -0,0 +1,130 @@
+package frontend
+
+import (
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"todo-cli/backend"
+)
+
+// force is set by --force, changes are sent without If-Match and
+// overwrite the changes made elsewhere
+var force bool
+
+// todoPath matches the path of a single todo, like /todos/12
+var todoPath = regexp.MustCompile(`/todos/(\d+)$`)
+
+// todoVersions holds the version of every todo the client saw last, by
+// server
+type todoVersions map[string]map[int]int
+
+// addForceFlag adds --force to a command which changes a todo
+func addForceFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite the todo even if it was changed since you last saw it")
+}
+
+// versionsPath returns versions.yaml next to the client config
+func versionsPath() (string, error) {
+	path, err := clientConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(path), "versions.yaml"), nil
+}
+
+// loadVersions reads the versions the client saw, a missing or broken
+// file only means the next changes are sent without If-Match
+func loadVersions() todoVersions {
+	versions := todoVersions{}
+	path, err := versionsPath()
+	if err != nil {
+		return versions
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		_ = yaml.Unmarshal(data, &versions)
+	}
+	return versions
+}
+
+func (v todoVersions) save() error {
+	path, err := versionsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// rememberVersion records the version of a todo the client read or
+// changed on its own, the next change of the todo is sent with If-Match
+func rememberVersion(todo backend.Todo) {
+	if todo.ID == 0 || todo.Version == 0 {
+		return
+	}
+	updateVersions(func(versions map[int]int) {
+		versions[todo.ID] = todo.Version
+	})
+}
+
+// forgetVersion drops the version of a deleted todo
+func forgetVersion(id string) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return
+	}
+	updateVersions(func(versions map[int]int) {
+		delete(versions, n)
+	})
+}
+
+// updateVersions changes the versions of the server of the active
+// profile and saves them, the versions are only a hint so errors are
+// left out
+func updateVersions(change func(versions map[int]int)) {
+	server, err := Endpoint("")
+	if err != nil {
+		return
+	}
+	versions := loadVersions()
+	if versions[server] == nil {
+		versions[server] = map[int]int{}
+	}
+	change(versions[server])
+	if len(versions[server]) == 0 {
+		delete(versions, server)
+	}
+	_ = versions.save()
+}
+
+// setIfMatch sends a change of a single todo with the version the client
+// saw last, the server turns it down if the todo was changed since
+func setIfMatch(req *http.Request) {
+	if force || (req.Method != http.MethodPut && req.Method != http.MethodPatch && req.Method != http.MethodDelete) {
+		return
+	}
+	match := todoPath.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		return
+	}
+	id, _ := strconv.Atoi(match[1])
+	server, err := Endpoint("")
+	if err != nil {
+		return
+	}
+	if version, ok := loadVersions()[server][id]; ok {
+		req.Header.Set("If-Match", `"`+strconv.Itoa(version)+`"`)
+	}
+}